@@ -3,21 +3,33 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/jmoiron/sqlx"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/bot"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/bot/middleware"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/config"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/digest"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/feed"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/fetcher"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/lang"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/leader"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/notifier"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/page"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/publisher"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/render"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/route"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/storage"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/summary"
 	_ "github.com/lib/pq"
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 )
 
 func main() {
@@ -36,25 +48,140 @@ func main() {
 	}
 	defer db.Close()
 
+	// Загружаем шаблоны постов. Ошибка в шаблоне должна обнаружиться сразу, а не при отправке статьи
+	parseMode, err := markup.ParseMode(config.Get().TelegramParseMode)
+	if err != nil {
+		log.Printf("invalid telegram parse mode: %v", err)
+		return
+	}
+
+	renderer, err := render.Load(config.Get().TemplatesDir, parseMode)
+	if err != nil {
+		log.Printf("failed to load post templates: %v", err)
+		return
+	}
+
+	digestRenderer, err := render.LoadDigest(config.Get().TemplatesDir)
+	if err != nil {
+		log.Printf("failed to load digest templates: %v", err)
+		return
+	}
+
+	prices, err := summary.ParsePrices(config.Get().OpenAIPrices)
+	if err != nil {
+		log.Printf("invalid openai prices: %v", err)
+		return
+	}
+
+	// Учет расхода токенов LLM и лимиты трат
+	usageStorage := storage.NewUsageStorage(db)
+	budget := summary.NewBudget(
+		usageStorage,
+		prices,
+		config.Get().LLMDailyBudget,
+		config.Get().LLMMonthlyBudget,
+	)
+
+	targetLanguages, err := lang.ParseTargets(config.Get().TranslationTargets)
+	if err != nil {
+		log.Printf("invalid translation targets: %v", err)
+		return
+	}
+
+	languageRoutes, err := route.Parse(config.Get().DestinationLanguages)
+	if err != nil {
+		log.Printf("invalid destination languages: %v", err)
+		return
+	}
+
+	tagRoutes, err := route.Parse(config.Get().DestinationTags)
+	if err != nil {
+		log.Printf("invalid destination tags: %v", err)
+		return
+	}
+
+	// Клиент LLM нужен только бэкенду openai. Через него же работают перевод и классификация
+	var llm *summary.OpenAISummarizer
+	if config.Get().SummarizerBackend == "openai" {
+		llm = newOpenAI(budget)
+	}
+
+	summarizer, err := newSummarizer(config.Get().SummarizerBackend, llm)
+	if err != nil {
+		log.Printf("failed to create summarizer: %v", err)
+		return
+	}
+
+	// Кнопки под постами
+	keyboard := render.Keyboard{
+		ReadButton:   config.Get().PostReadButton,
+		DiscussURL:   config.Get().PostDiscussURL,
+		SourceButton: config.Get().PostSourceButton,
+		Reactions:    config.Get().PostReactions,
+	}
+
 	// Инициализируем наши зависимости
 	var (
-		articleStorage = storage.NewArticleStorage(db)
-		sourceStorage  = storage.NewSourcePostgresStorage(db)
-		fetcher        = fetcher.NewFetcher(
+		articleStorage  = storage.NewArticleStorage(db)
+		sourceStorage   = storage.NewSourcePostgresStorage(db)
+		reactionStorage = storage.NewReactionStorage(db)
+		digestStorage   = storage.NewDigestStorage(db)
+		summaryStorage  = storage.NewSummaryStorage(db)
+		translations    = storage.NewTranslationStorage(db)
+		tagStorage      = storage.NewTagStorage(db)
+		relevance       = storage.NewRelevanceStorage(db)
+		extractionRules = storage.NewExtractionRuleStorage(db)
+		telegram        = publisher.NewTelegram(
+			botAPI,
+			config.Get().TelegramChannelID,
+			config.Get().ModerationChatID,
+			renderer,
+			keyboard,
+			reactionStorage,
+		)
+		destinations = publishers(telegram)
+		fetcher      = fetcher.NewFetcher(
 			articleStorage,
 			sourceStorage,
 			config.Get().FetchInterval,
 			config.Get().FilterKeywords,
+			config.Get().FilterLanguages,
 		)
 		notifier = notifier.New(
 			articleStorage,
-			summary.NewOpenAISummarizer(config.Get().OpenAIKey, config.Get().OpenAIPromt),
-			botAPI,
+			sourceStorage,
+			page.NewFetcher(
+				config.Get().PageTimeout,
+				config.Get().PageMaxRedirects,
+				config.Get().PageMaxBytes,
+				config.Get().PageMaxAttempts,
+				config.Get().PageRetryBackoff,
+			),
+			page.NewExtractor(extractionRules, config.Get().ExtractionMinLength),
+			summarizer,
+			summaryStorage,
+			newTranslator(llm, len(targetLanguages) > 0),
+			translations,
+			targetLanguages,
+			newClassifier(llm),
+			tagStorage,
+			config.Get().FilterTags,
+			config.Get().FilterLanguages,
+			languageRoutes,
+			tagRoutes,
+			newScorer(llm),
+			relevance,
+			renderer,
+			destinations,
+			moderationSender(telegram),
 			// Интервал отправки сообщений
 			config.Get().NotificationInterval,
 			// Интервал которым мы будем заглядывать в прошлое (lookapthewindow)
 			2*config.Get().FetchInterval,
-			config.Get().TelegramChannelID,
+			config.Get().MaxPostAttempts,
+			config.Get().PostRetryBackoff,
+			config.Get().ClaimLease,
+			config.Get().ModerationTimeout,
 		)
 	)
 
@@ -74,31 +201,238 @@ func main() {
 		),
 	)
 	newsBot.RegisterCmdView("listsources", bot.ViewCmdListSources(sourceStorage))
+	newsBot.RegisterCallbackView(
+		render.ReactionCallbackPrefix,
+		bot.ViewCallbackReaction(reactionStorage, articleStorage, sourceStorage, keyboard),
+	)
+	newsBot.RegisterCallbackView(
+		render.ModerationCallbackPrefix,
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCallbackModeration(articleStorage),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"editsummary",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdEditSummary(articleStorage),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"preview",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdPreview(notifier, renderer),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"resummarize",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdResummarize(notifier),
+		),
+	)
+	newsBot.SetCmdTimeout("preview", config.Get().LongCommandTimeout)
+	newsBot.SetCmdTimeout("resummarize", config.Get().LongCommandTimeout)
+	newsBot.RegisterCmdView(
+		"setsummary",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdSetSummary(sourceStorage, summaryStorage),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"summarysettings",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdSummarySettings(sourceStorage, summaryStorage),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"addtag",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdAddTag(tagStorage),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"removetag",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdRemoveTag(tagStorage),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"listtags",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdListTags(tagStorage),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"setbrief",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdSetBrief(relevance, destinationNames(destinations), config.Get().RelevanceThreshold),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"removebrief",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdRemoveBrief(relevance),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"listbriefs",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdListBriefs(relevance),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"setextraction",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdSetExtraction(extractionRules),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"removeextraction",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdRemoveExtraction(extractionRules),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"listextractions",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdListExtractions(extractionRules),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"why",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdWhy(articleStorage, relevance),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"usage",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdUsage(usageStorage, config.Get().LLMDailyBudget, config.Get().LLMMonthlyBudget),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"unpost",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdUnpost(articleStorage),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"listfailed",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdListFailed(articleStorage),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"retry",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdRetry(articleStorage),
+		),
+	)
 
-	// Воркер fetcher
-	go func(ctx context.Context) {
-		if err := fetcher.Start(ctx); err != nil {
-			if !errors.Is(err, context.Canceled) {
-				log.Printf("[ERROR] failed to start fetcher: %v", err)
-				return
-			}
+	newsBot.RegisterCmdView(
+		"addrecipient",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdAddRecipient(digestStorage),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"removerecipient",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdRemoveRecipient(digestStorage),
+		),
+	)
+	newsBot.RegisterCmdView(
+		"listrecipients",
+		middleware.AdminOnly(
+			config.Get().TelegramChannelID,
+			bot.ViewCmdListRecipients(digestStorage),
+		),
+	)
 
-			log.Println("fetcher stopped")
-		}
-	}(ctx)
+	workers := []worker{
+		{name: "fetcher", starter: fetcher},
+		{name: "notifier", starter: notifier},
+	}
 
-	// Воркер notifier
-	go func(ctx context.Context) {
-		if err := notifier.Start(ctx); err != nil {
-			if !errors.Is(err, context.Canceled) {
-				log.Printf("[ERROR] failed to start notifier: %v", err)
-				return
-			}
+	// Дайджест включается, если задан его период
+	if config.Get().DigestPeriod > 0 {
+		workers = append(workers, worker{
+			name: "digest",
+			starter: digest.New(
+				articleStorage,
+				sourceStorage,
+				digestStorage,
+				publisher.NewEmail(
+					config.Get().SMTPHost,
+					config.Get().SMTPPort,
+					config.Get().SMTPUsername,
+					config.Get().SMTPPassword,
+					config.Get().SMTPFrom,
+					config.Get().SMTPStartTLS,
+				),
+				digestRenderer,
+				config.Get().DigestPeriod,
+				config.Get().DigestSubject,
+			),
+		})
+	}
+
+	// Фоновые воркеры запускаются только на инстансе-лидере, а команды бота обслуживают все инстансы
+	elector := leader.NewElector(
+		db,
+		config.Get().LeaderLockID,
+		config.Get().LeaderRetryInterval,
+		config.Get().LeaderCheckInterval,
+	)
 
-			log.Println("notifier stopped")
+	go func(ctx context.Context) {
+		if err := elector.Run(ctx, func(ctx context.Context) error {
+			runWorkers(ctx, workers)
+			return nil
+		}); err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("[ERROR] leader election stopped: %v", err)
 		}
 	}(ctx)
 
+	// Сервер исходящей ленты только читает из БД, поэтому работает на всех инстансах, а не только на лидере
+	if addr := config.Get().FeedListenAddr; addr != "" {
+		feedServer := feed.NewServer(
+			articleStorage,
+			sourceStorage,
+			addr,
+			config.Get().FeedBaseURL,
+			config.Get().FeedTitle,
+			config.Get().FeedPageSize,
+		)
+
+		go func(ctx context.Context) {
+			if err := feedServer.Start(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("[ERROR] feed server stopped: %v", err)
+			}
+		}(ctx)
+	}
+
 	// Запуск бота
 	if err := newsBot.Run(ctx); err != nil {
 		if !errors.Is(err, context.Canceled) {
@@ -110,3 +444,173 @@ func main() {
 	}
 
 }
+
+// Фоновый воркер, который работает до отмены контекста
+type worker struct {
+	name    string
+	starter interface {
+		Start(ctx context.Context) error
+	}
+}
+
+// Задержка перед перезапуском упавшего воркера, с каждым падением подряд удваивается до workerMaxBackoff
+const (
+	workerBackoff    = time.Second
+	workerMaxBackoff = time.Minute
+)
+
+// Запускает фоновые воркеры и ждет, пока все они завершатся
+func runWorkers(ctx context.Context, workers []worker) {
+	var wg sync.WaitGroup
+	wg.Add(len(workers))
+
+	for _, w := range workers {
+		go func(w worker) {
+			defer wg.Done()
+			runWorker(ctx, w)
+		}(w)
+	}
+
+	wg.Wait()
+}
+
+// Запускает воркер и перезапускает его, если он завершился до отмены ctx.
+// Иначе инстанс так и остался бы лидером, но без этого воркера
+func runWorker(ctx context.Context, w worker) {
+	backoff := workerBackoff
+
+	for {
+		startedAt := time.Now()
+		err := w.starter.Start(ctx)
+
+		if ctx.Err() != nil {
+			log.Printf("%s stopped", w.name)
+			return
+		}
+
+		// Воркер успел поработать, значит это не падение при каждом запуске: начинаем отсчет задержки заново
+		if time.Since(startedAt) > workerMaxBackoff {
+			backoff = workerBackoff
+		}
+
+		log.Printf("[ERROR] %s stopped unexpectedly, restarting in %s: %v", w.name, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > workerMaxBackoff {
+			backoff = workerMaxBackoff
+		}
+	}
+}
+
+// Клиент OpenAI-совместимого API. Лимиты нагрузки общие для summary, перевода, тегов и оценки релевантности
+func newOpenAI(budget *summary.Budget) *summary.OpenAISummarizer {
+	return summary.NewOpenAISummarizer(
+		config.Get().OpenAIKey,
+		config.Get().OpenAIBaseURL,
+		config.Get().OpenAIModel,
+		config.Get().OpenAITemperature,
+		config.Get().OpenAIMaxTokens,
+		config.Get().OpenAIChunkTokens,
+		config.Get().SummaryMaxInputTokens,
+		config.Get().OpenAIPromt,
+		config.Get().SummaryStructured,
+		budget,
+		summary.NewLimiter(config.Get().OpenAIConcurrency, config.Get().OpenAIRPM, config.Get().OpenAITPM),
+		// После серии ошибок перестаем дергать API на время, посты в это время уходят без LLM summary, перевода и тегов
+		summary.NewCircuitBreaker(config.Get().OpenAIBreakerThreshold, config.Get().OpenAIBreakerCooldown),
+	)
+}
+
+// Выбирает бэкенд для генерации summary по имени из конфига.
+// LLM бэкенд по умолчанию подстрахован экстрактивным summarizer, который работает без сети
+func newSummarizer(backend string, llm *summary.OpenAISummarizer) (notifier.Summarizer, error) {
+	extractive := summary.NewTextRankSummarizer(config.Get().ExtractiveSentences)
+
+	switch backend {
+	case "openai":
+		if !config.Get().SummaryFallback {
+			return llm, nil
+		}
+
+		return summary.NewFallbackSummarizer(llm, extractive), nil
+	case "textrank":
+		return extractive, nil
+	case "none":
+		return summary.NewNoopSummarizer(), nil
+	default:
+		return nil, fmt.Errorf("unknown summarizer backend %q", backend)
+	}
+}
+
+// Переводчик работает через LLM и создается, только если нужен перевод.
+// Иначе возвращается nil интерфейс, и статьи публикуются на языке оригинала
+func newTranslator(llm *summary.OpenAISummarizer, translate bool) notifier.Translator {
+	if llm == nil || !llm.Enabled() || !translate {
+		return nil
+	}
+
+	return summary.NewOpenAITranslator(llm, config.Get().TranslationGlossary)
+}
+
+// Классификатор по тегам работает через LLM и включается в конфиге. Иначе возвращается nil интерфейс
+func newClassifier(llm *summary.OpenAISummarizer) notifier.Classifier {
+	if llm == nil || !llm.Enabled() || !config.Get().AutoTagging {
+		return nil
+	}
+
+	return summary.NewOpenAIClassifier(llm, config.Get().AutoTagMaxTags)
+}
+
+// Оценка релевантности работает через LLM и включается в конфиге. Иначе возвращается nil интерфейс
+func newScorer(llm *summary.OpenAISummarizer) notifier.Scorer {
+	if llm == nil || !llm.Enabled() || !config.Get().RelevanceScoring {
+		return nil
+	}
+
+	return summary.NewOpenAIScorer(llm)
+}
+
+// Собирает места назначения: канал телеграма и те дополнительные, для которых задан адрес в конфиге
+func publishers(telegram *publisher.Telegram) []notifier.Publisher {
+	result := []notifier.Publisher{telegram}
+
+	if url := config.Get().DiscordWebhookURL; url != "" {
+		result = append(result, publisher.NewDiscord(url))
+	}
+
+	if url := config.Get().SlackWebhookURL; url != "" {
+		result = append(result, publisher.NewSlack(url))
+	}
+
+	if url := config.Get().WebhookURL; url != "" {
+		result = append(result, publisher.NewWebhook(url, config.Get().WebhookSecret))
+	}
+
+	return result
+}
+
+// Модерация включена, только если задан чат редакторов.
+// Возвращаем именно nil интерфейс, а не nil указатель, иначе notifier посчитает модерацию включенной
+func moderationSender(telegram *publisher.Telegram) notifier.ModerationSender {
+	if config.Get().ModerationChatID == 0 {
+		return nil
+	}
+
+	return telegram
+}
+
+// Имена мест назначения, для которых можно задать бриф
+func destinationNames(destinations []notifier.Publisher) []string {
+	names := make([]string, 0, len(destinations))
+	for _, destination := range destinations {
+		names = append(names, destination.Name())
+	}
+
+	return names
+}
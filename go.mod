@@ -4,22 +4,22 @@ go 1.19
 
 require (
 	github.com/SlyMarbo/rss v1.0.5
+	github.com/andybalholm/cascadia v1.3.2
 	github.com/cristalhq/aconfig v0.18.4
 	github.com/cristalhq/aconfig/aconfighcl v0.17.1
 	github.com/go-shiori/go-readability v0.0.0-20230421032831-c66949dfc0ad
 	github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
+	github.com/gogs/chardet v0.0.0-20211120154057-b7413eaefb8f
 	github.com/lib/pq v1.2.0
 	github.com/samber/lo v1.38.1
 	github.com/sashabaranov/go-openai v1.14.2
 	github.com/tomakado/containers v0.0.0-20230620211702-4a5ca7fb9fd3
+	golang.org/x/net v0.14.0
 )
 
 require (
-	github.com/andybalholm/cascadia v1.3.2 // indirect
 	github.com/go-shiori/dom v0.0.0-20230515143342-73569d674e1c // indirect
-	github.com/gogs/chardet v0.0.0-20211120154057-b7413eaefb8f // indirect
 	github.com/hashicorp/hcl v1.0.0 // indirect
-	golang.org/x/net v0.14.0 // indirect
 	golang.org/x/text v0.12.0 // indirect
 )
 
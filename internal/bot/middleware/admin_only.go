@@ -6,6 +6,8 @@ import (
 	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
 )
 
+const noRightsText = "У вас нет прав для выполнения этой команды"
+
 func AdminOnly(channelID int64, next botkit.ViewFunc) botkit.ViewFunc {
 	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
 		admins, err := bot.GetChatAdministrators(
@@ -19,14 +21,25 @@ func AdminOnly(channelID int64, next botkit.ViewFunc) botkit.ViewFunc {
 			return err
 		}
 
+		// View может обрабатывать как команду, так и нажатие на inline кнопку, поэтому отправителя берем из update
+		sender := update.SentFrom()
+		if sender == nil {
+			return nil
+		}
+
 		// Проверка на то, что тот кто отправил команду находится в списке администраторов
 		for _, admin := range admins {
-			if admin.User.ID == update.Message.From.ID {
+			if admin.User.ID == sender.ID {
 				return next(ctx, bot, update)
 			}
 		}
 
-		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "У вас нет прав для выполнения этой команды")); err != nil {
+		if update.CallbackQuery != nil {
+			_, err := bot.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, noRightsText))
+			return err
+		}
+
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, noRightsText)); err != nil {
 			return err
 		}
 		return nil
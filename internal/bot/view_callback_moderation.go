@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/render"
+	"strconv"
+)
+
+type ArticleModerator interface {
+	Moderate(ctx context.Context, id int64, status model.ModerationStatus, userID int64, userName string) error
+}
+
+// Обработка нажатий на кнопки модерации: одобрить, отклонить или изменить summary статьи
+func ViewCallbackModeration(moderator ArticleModerator) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		query := update.CallbackQuery
+
+		args := botkit.CallbackArgs(update)
+		if len(args) != 2 {
+			return fmt.Errorf("unexpected moderation callback data %q", query.Data)
+		}
+
+		articleID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return err
+		}
+
+		var (
+			status model.ModerationStatus
+			result string
+		)
+
+		switch args[0] {
+		case render.ModerationActionApprove:
+			status, result = model.ModerationApproved, "✅ Одобрено"
+		case render.ModerationActionReject:
+			status, result = model.ModerationRejected, "❌ Отклонено"
+		case render.ModerationActionEdit:
+			// Новый текст summary присылается отдельной командой, здесь только подсказываем как это сделать
+			hint := fmt.Sprintf("Чтобы изменить summary, отправьте: /editsummary %d новый текст", articleID)
+			if _, err := bot.Send(tgbotapi.NewMessage(query.Message.Chat.ID, hint)); err != nil {
+				return err
+			}
+
+			_, err := bot.Request(tgbotapi.NewCallback(query.ID, ""))
+			return err
+		default:
+			return fmt.Errorf("unknown moderation action %q", args[0])
+		}
+
+		moderatorName := query.From.UserName
+		if moderatorName == "" {
+			moderatorName = query.From.FirstName
+		}
+
+		if err := moderator.Moderate(ctx, articleID, status, query.From.ID, moderatorName); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			_, err := bot.Request(tgbotapi.NewCallback(query.ID, "Решение по статье уже принято"))
+			return err
+		}
+
+		// Убираем кнопки, чтобы по статье нельзя было принять второе решение, и отмечаем кто его принял
+		removeButtons := tgbotapi.NewEditMessageReplyMarkup(
+			query.Message.Chat.ID,
+			query.Message.MessageID,
+			tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}},
+		)
+		if _, err := bot.Request(removeButtons); err != nil {
+			return err
+		}
+
+		reply := tgbotapi.NewMessage(query.Message.Chat.ID, fmt.Sprintf("%s: %s", result, moderatorName))
+		reply.ReplyToMessageID = query.Message.MessageID
+		if _, err := bot.Send(reply); err != nil {
+			return err
+		}
+
+		if _, err := bot.Request(tgbotapi.NewCallback(query.ID, result)); err != nil {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/render"
+	"strconv"
+)
+
+type ReactionStorage interface {
+	Toggle(ctx context.Context, articleID int64, userID int64, reaction string) error
+	Counts(ctx context.Context, articleID int64) (map[string]int, error)
+}
+
+type ArticleGetter interface {
+	ArticleByID(ctx context.Context, id int64) (*model.Article, error)
+}
+
+type SourceGetter interface {
+	SourceByID(ctx context.Context, id int64) (*model.Source, error)
+}
+
+// Обработка нажатия на кнопку реакции под постом.
+// Ставит или снимает реакцию пользователя и обновляет счетчики на кнопках
+func ViewCallbackReaction(
+	reactions ReactionStorage,
+	articles ArticleGetter,
+	sources SourceGetter,
+	keyboard render.Keyboard,
+) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		query := update.CallbackQuery
+
+		args := botkit.CallbackArgs(update)
+		if len(args) != 2 {
+			return fmt.Errorf("unexpected reaction callback data %q", query.Data)
+		}
+
+		articleID, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return err
+		}
+
+		idx, err := strconv.Atoi(args[1])
+		if err != nil {
+			return err
+		}
+
+		// Набор реакций мог поменяться после публикации поста
+		if idx < 0 || idx >= len(keyboard.Reactions) {
+			_, err := bot.Request(tgbotapi.NewCallback(query.ID, "Эта реакция больше недоступна"))
+			return err
+		}
+
+		reaction := keyboard.Reactions[idx]
+
+		if err := reactions.Toggle(ctx, articleID, query.From.ID, reaction); err != nil {
+			return err
+		}
+
+		counts, err := reactions.Counts(ctx, articleID)
+		if err != nil {
+			return err
+		}
+
+		article, err := articles.ArticleByID(ctx, articleID)
+		if err != nil {
+			return err
+		}
+
+		source, err := sources.SourceByID(ctx, article.SourceID)
+		if err != nil {
+			return err
+		}
+
+		if query.Message != nil {
+			if markup := keyboard.Build(*article, *source, counts); markup != nil {
+				edit := tgbotapi.NewEditMessageReplyMarkup(query.Message.Chat.ID, query.Message.MessageID, *markup)
+				if _, err := bot.Request(edit); err != nil {
+					return err
+				}
+			}
+		}
+
+		if _, err := bot.Request(tgbotapi.NewCallback(query.ID, reaction)); err != nil {
+			return err
+		}
+		return nil
+	}
+}
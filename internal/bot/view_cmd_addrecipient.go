@@ -0,0 +1,35 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"net/mail"
+	"strings"
+)
+
+type RecipientAdder interface {
+	AddRecipient(ctx context.Context, email string) error
+}
+
+// Добавляет адрес в рассылку email дайджеста
+func ViewCmdAddRecipient(adder RecipientAdder) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		address, err := mail.ParseAddress(strings.TrimSpace(update.Message.CommandArguments()))
+		if err != nil {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "Использование: /addrecipient EMAIL"))
+			return err
+		}
+
+		if err := adder.AddRecipient(ctx, address.Address); err != nil {
+			return err
+		}
+
+		msgText := fmt.Sprintf("Адрес %s добавлен в рассылку дайджеста", address.Address)
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, msgText)); err != nil {
+			return err
+		}
+		return nil
+	}
+}
@@ -2,10 +2,11 @@ package bot
 
 import (
 	"context"
-	"fmt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"strconv"
 )
 
 type SourceStorage interface {
@@ -36,15 +37,13 @@ func ViewCmdAddSource(storage SourceStorage) botkit.ViewFunc {
 			return err
 		}
 
-		var (
-			msgText = fmt.Sprintf(
-				"Источник добавлен с ID: `%d`\\. Используйте этот ID для управления источником\\.",
-				sourceID,
-			)
-			reply = tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
-		)
+		msg := markup.New().
+			Text("Источник добавлен с ID: ").
+			Code(strconv.FormatInt(sourceID, 10)).
+			Text(". Используйте этот ID для управления источником.")
 
-		reply.ParseMode = "MarkdownV2"
+		reply := tgbotapi.NewMessage(update.Message.Chat.ID, msg.Render(markup.MarkdownV2))
+		reply.ParseMode = markup.MarkdownV2.String()
 
 		if _, err := bot.Send(reply); err != nil {
 			return err
@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"strings"
+)
+
+type TagAdder interface {
+	AddTag(ctx context.Context, tag model.Tag) error
+}
+
+// Максимальная длина названия тега, как в таблице tags
+const maxTagLen = 64
+
+// Добавляет тег в словарь, из которого LLM выбирает теги статей. Описание подсказывает LLM, какие статьи подходят под тег
+func ViewCmdAddTag(adder TagAdder) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		name, description, _ := strings.Cut(strings.TrimSpace(update.Message.CommandArguments()), " ")
+		name = strings.ToLower(strings.TrimPrefix(name, "#"))
+
+		if name == "" || len([]rune(name)) > maxTagLen {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "Использование: /addtag НАЗВАНИЕ [описание]"))
+			return err
+		}
+
+		if err := adder.AddTag(ctx, model.Tag{Name: name, Description: strings.TrimSpace(description)}); err != nil {
+			return err
+		}
+
+		msgText := fmt.Sprintf("Тег %s добавлен в словарь", name)
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, msgText)); err != nil {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"strconv"
+	"strings"
+)
+
+type SummaryEditor interface {
+	SetEditedSummary(ctx context.Context, id int64, summary string) error
+}
+
+// Заменяет summary статьи текстом редактора. Аргументы: id статьи и новый текст
+func ViewCmdEditSummary(editor SummaryEditor) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		rawID, summary, _ := strings.Cut(strings.TrimSpace(update.Message.CommandArguments()), " ")
+		summary = strings.TrimSpace(summary)
+
+		articleID, err := strconv.ParseInt(rawID, 10, 64)
+		if err != nil || summary == "" {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "Использование: /editsummary ID новый текст"))
+			return err
+		}
+
+		msgText := fmt.Sprintf("Summary статьи %d обновлено", articleID)
+		if err := editor.SetEditedSummary(ctx, articleID, summary); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			msgText = fmt.Sprintf("Статья %d не найдена или уже опубликована", articleID)
+		}
+
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, msgText)); err != nil {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,45 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+)
+
+type BriefLister interface {
+	Briefs(ctx context.Context) ([]model.Brief, error)
+}
+
+// Показывает редакционные брифы мест назначения
+func ViewCmdListBriefs(lister BriefLister) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		briefs, err := lister.Briefs(ctx)
+		if err != nil {
+			return err
+		}
+
+		msg := markup.New().Text("Брифы не заданы, статьи публикуются без оценки релевантности")
+		if len(briefs) > 0 {
+			msg = markup.New().Text(fmt.Sprintf("Брифы мест назначения (всего %d):", len(briefs)))
+		}
+
+		for _, brief := range briefs {
+			msg.Line().Line().
+				Bold(brief.Destination).Text(fmt.Sprintf(", порог %d", brief.Threshold)).
+				Line().Text(brief.Text)
+		}
+
+		for _, part := range msg.Split(markup.MaxMessageLen) {
+			reply := tgbotapi.NewMessage(update.Message.Chat.ID, part.Render(markup.MarkdownV2))
+			reply.ParseMode = markup.MarkdownV2.String()
+
+			if _, err := bot.Send(reply); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
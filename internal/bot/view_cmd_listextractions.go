@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"strings"
+)
+
+type ExtractionRuleLister interface {
+	Rules(ctx context.Context) ([]model.ExtractionRule, error)
+}
+
+// Показывает правила извлечения текста статей по доменам
+func ViewCmdListExtractions(lister ExtractionRuleLister) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		rules, err := lister.Rules(ctx)
+		if err != nil {
+			return err
+		}
+
+		msg := markup.New().Text("Правила не заданы, текст всех статей выделяет readability")
+		if len(rules) > 0 {
+			msg = markup.New().Text(fmt.Sprintf("Правила извлечения текста (всего %d):", len(rules)))
+		}
+
+		for _, rule := range rules {
+			msg.Line().Line().Bold(rule.Domain)
+			if rule.ContentSelector != "" {
+				msg.Line().Text("Текст: ").Code(rule.ContentSelector)
+			}
+			if len(rule.StripSelectors) > 0 {
+				msg.Line().Text("Удаляются: ").Code(strings.Join(rule.StripSelectors, ", "))
+			}
+			if rule.PaywallSelector != "" {
+				msg.Line().Text("Paywall: ").Code(rule.PaywallSelector)
+			}
+		}
+
+		for _, part := range msg.Split(markup.MaxMessageLen) {
+			reply := tgbotapi.NewMessage(update.Message.Chat.ID, part.Render(markup.MarkdownV2))
+			reply.ParseMode = markup.MarkdownV2.String()
+
+			if _, err := bot.Send(reply); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
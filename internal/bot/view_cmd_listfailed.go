@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"strconv"
+)
+
+// Сколько статей из dead-letter показываем за раз
+const failedArticlesLimit = 20
+
+type DeadArticleLister interface {
+	AllDead(ctx context.Context, limit uint64) ([]model.Article, error)
+}
+
+// Вывод списка статей, которые не удалось отправить и которые попали в dead-letter
+func ViewCmdListFailed(lister DeadArticleLister) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		articles, err := lister.AllDead(ctx, failedArticlesLimit)
+		if err != nil {
+			return err
+		}
+
+		msg := markup.New().Text("Статей в dead-letter нет")
+		if len(articles) > 0 {
+			msg = markup.New().Text(fmt.Sprintf("Статьи в dead-letter (показано %d):", len(articles)))
+			for _, article := range articles {
+				msg.Line().Line().Add(formatFailedArticle(article)...)
+			}
+			msg.Line().Line().Text("Чтобы отправить статью повторно, используйте /retry ID")
+		}
+
+		reply := tgbotapi.NewMessage(update.Message.Chat.ID, msg.Truncate(markup.MaxMessageLen).Render(markup.MarkdownV2))
+		reply.ParseMode = markup.MarkdownV2.String()
+
+		if _, err := bot.Send(reply); err != nil {
+			return err
+		}
+		return nil
+	}
+}
+
+// Вывод форматированной информации о статье в dead-letter
+func formatFailedArticle(article model.Article) []markup.Node {
+	return []markup.Node{
+		markup.Text("❌ "),
+		markup.Bold(markup.Text(article.Title)),
+		markup.Text("\nID: "),
+		markup.Code(strconv.FormatInt(article.ID, 10)),
+		markup.Text(fmt.Sprintf(
+			"\nПопыток: %d, последняя в %s\nОшибка: %s",
+			article.Attempts,
+			article.DeadAt.Format("02.01.2006 15:04"),
+			article.LastError,
+		)),
+	}
+}
@@ -0,0 +1,33 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"strings"
+)
+
+type RecipientLister interface {
+	Recipients(ctx context.Context) ([]string, error)
+}
+
+// Показывает адреса, на которые рассылается email дайджест
+func ViewCmdListRecipients(lister RecipientLister) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		recipients, err := lister.Recipients(ctx)
+		if err != nil {
+			return err
+		}
+
+		msgText := "В рассылке дайджеста нет адресов"
+		if len(recipients) > 0 {
+			msgText = fmt.Sprintf("Получатели дайджеста (всего %d):\n%s", len(recipients), strings.Join(recipients, "\n"))
+		}
+
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, msgText)); err != nil {
+			return err
+		}
+		return nil
+	}
+}
@@ -7,8 +7,7 @@ import (
 	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
-	"github.com/samber/lo"
-	"strings"
+	"strconv"
 )
 
 type SourceLister interface {
@@ -23,23 +22,19 @@ func ViewCmdListSources(lister SourceLister) botkit.ViewFunc {
 			return err
 		}
 
-		var (
-			// Складываем в нее сформатированные тексты с метаинформацией об источниках
-			sourceInfos = lo.Map(sources, func(source model.Source, _ int) string {
-				return formatSource(source)
-			})
-			msgText = fmt.Sprintf(
-				"Список источников \\(всего %d\\):\n\n%s",
-				len(sources),
-				strings.Join(sourceInfos, "\n\n"),
-			)
-		)
-
-		reply := tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
-		reply.ParseMode = "MarkdownV2"
-
-		if _, err := bot.Send(reply); err != nil {
-			return err
+		msg := markup.New().Text(fmt.Sprintf("Список источников (всего %d):", len(sources)))
+		for _, source := range sources {
+			msg.Line().Line().Add(formatSource(source)...)
+		}
+
+		// Источников может быть много, поэтому длинный список отправляем несколькими сообщениями
+		for _, part := range msg.Split(markup.MaxMessageLen) {
+			reply := tgbotapi.NewMessage(update.Message.Chat.ID, part.Render(markup.MarkdownV2))
+			reply.ParseMode = markup.MarkdownV2.String()
+
+			if _, err := bot.Send(reply); err != nil {
+				return err
+			}
 		}
 		return nil
 
@@ -47,11 +42,12 @@ func ViewCmdListSources(lister SourceLister) botkit.ViewFunc {
 }
 
 // Вывод форматированной информации об источниках
-func formatSource(source model.Source) string {
-	return fmt.Sprintf(
-		"🌐 *%s*\nID: `%d`\nURL фида: %s",
-		markup.EscapeForMarkdown(source.Name),
-		source.ID,
-		markup.EscapeForMarkdown(source.FeedURL),
-	)
+func formatSource(source model.Source) []markup.Node {
+	return []markup.Node{
+		markup.Text("🌐 "),
+		markup.Bold(markup.Text(source.Name)),
+		markup.Text("\nID: "),
+		markup.Code(strconv.FormatInt(source.ID, 10)),
+		markup.Text("\nURL фида: " + source.FeedURL),
+	}
 }
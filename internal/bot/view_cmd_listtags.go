@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+)
+
+type TagLister interface {
+	Tags(ctx context.Context) ([]model.Tag, error)
+}
+
+// Показывает словарь тегов
+func ViewCmdListTags(lister TagLister) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		tags, err := lister.Tags(ctx)
+		if err != nil {
+			return err
+		}
+
+		msg := markup.New().Text("Словарь тегов пуст")
+		if len(tags) > 0 {
+			msg = markup.New().Text(fmt.Sprintf("Словарь тегов (всего %d):", len(tags)))
+		}
+
+		for _, tag := range tags {
+			msg.Line().Code(tag.Name)
+			if tag.Description != "" {
+				msg.Text(" - " + tag.Description)
+			}
+		}
+
+		for _, part := range msg.Split(markup.MaxMessageLen) {
+			reply := tgbotapi.NewMessage(update.Message.Chat.ID, part.Render(markup.MarkdownV2))
+			reply.ParseMode = markup.MarkdownV2.String()
+
+			if _, err := bot.Send(reply); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
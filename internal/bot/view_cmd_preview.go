@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
+	"strconv"
+	"strings"
+)
+
+type ArticlePreviewer interface {
+	Preview(ctx context.Context, articleID int64, destination string) (string, error)
+}
+
+type Renderer interface {
+	Mode() markup.Mode
+}
+
+// Показывает, как будет выглядеть пост для выбранной статьи.
+// Аргументы: id статьи и, опционально, место назначения, для которого выбирается шаблон
+func ViewCmdPreview(previewer ArticlePreviewer, renderer Renderer) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		args := strings.Fields(update.Message.CommandArguments())
+		if len(args) == 0 || len(args) > 2 {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "Использование: /preview ID [destination]"))
+			return err
+		}
+
+		articleID, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "Использование: /preview ID [destination]"))
+			return err
+		}
+
+		var destination string
+		if len(args) == 2 {
+			destination = args[1]
+		}
+
+		text, err := previewer.Preview(ctx, articleID, destination)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Статья %d не найдена", articleID)))
+			return err
+		}
+
+		reply := tgbotapi.NewMessage(update.Message.Chat.ID, text)
+		reply.ParseMode = renderer.Mode().String()
+
+		if _, err := bot.Send(reply); err != nil {
+			return err
+		}
+		return nil
+	}
+}
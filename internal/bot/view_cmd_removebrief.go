@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"strings"
+)
+
+type BriefRemover interface {
+	RemoveBrief(ctx context.Context, destination string) error
+}
+
+// Удаляет бриф места назначения, после этого статьи публикуются туда без оценки релевантности
+func ViewCmdRemoveBrief(remover BriefRemover) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		destination := strings.TrimSpace(update.Message.CommandArguments())
+		if destination == "" {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "Использование: /removebrief DESTINATION"))
+			return err
+		}
+
+		msgText := fmt.Sprintf("Бриф для %s удален", destination)
+		if err := remover.RemoveBrief(ctx, destination); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			msgText = fmt.Sprintf("Для %s нет брифа", destination)
+		}
+
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, msgText)); err != nil {
+			return err
+		}
+		return nil
+	}
+}
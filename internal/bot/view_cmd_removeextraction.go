@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/page"
+)
+
+type ExtractionRuleRemover interface {
+	RemoveRule(ctx context.Context, domain string) error
+}
+
+// Удаляет правило извлечения текста для домена, после этого текст его статей выделяет readability
+func ViewCmdRemoveExtraction(remover ExtractionRuleRemover) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		domain := page.NormalizeDomain(update.Message.CommandArguments())
+		if domain == "" {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "Использование: /removeextraction DOMAIN"))
+			return err
+		}
+
+		msgText := fmt.Sprintf("Правило для %s удалено", domain)
+		if err := remover.RemoveRule(ctx, domain); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			msgText = fmt.Sprintf("Для %s нет правила", domain)
+		}
+
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, msgText)); err != nil {
+			return err
+		}
+		return nil
+	}
+}
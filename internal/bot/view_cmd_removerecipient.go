@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"strings"
+)
+
+type RecipientRemover interface {
+	RemoveRecipient(ctx context.Context, email string) error
+}
+
+// Убирает адрес из рассылки email дайджеста
+func ViewCmdRemoveRecipient(remover RecipientRemover) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		email := strings.TrimSpace(update.Message.CommandArguments())
+		if email == "" {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "Использование: /removerecipient EMAIL"))
+			return err
+		}
+
+		msgText := fmt.Sprintf("Адрес %s удален из рассылки дайджеста", email)
+		if err := remover.RemoveRecipient(ctx, email); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			msgText = fmt.Sprintf("Адреса %s нет в рассылке", email)
+		}
+
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, msgText)); err != nil {
+			return err
+		}
+		return nil
+	}
+}
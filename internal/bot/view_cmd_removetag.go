@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"strings"
+)
+
+type TagRemover interface {
+	RemoveTag(ctx context.Context, name string) error
+}
+
+// Убирает тег из словаря. Статьи, которым тег уже проставлен, сохраняют его
+func ViewCmdRemoveTag(remover TagRemover) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		name := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(update.Message.CommandArguments()), "#"))
+		if name == "" {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "Использование: /removetag НАЗВАНИЕ"))
+			return err
+		}
+
+		msgText := fmt.Sprintf("Тег %s удален из словаря", name)
+		if err := remover.RemoveTag(ctx, name); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			msgText = fmt.Sprintf("Тега %s нет в словаре", name)
+		}
+
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, msgText)); err != nil {
+			return err
+		}
+		return nil
+	}
+}
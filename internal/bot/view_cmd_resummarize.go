@@ -0,0 +1,51 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"strconv"
+	"strings"
+)
+
+type ArticleResummarizer interface {
+	Resummarize(ctx context.Context, articleID int64, promt string) (string, error)
+}
+
+const resummarizeUsage = "Использование: /resummarize ID [промпт] - сгенерировать summary статьи заново. Без промпта используется промпт по умолчанию"
+
+// Генерирует summary статьи заново, например с другим промптом. Если статья уже опубликована, пост будет отредактирован
+func ViewCmdResummarize(resummarizer ArticleResummarizer) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		idArg, promt, _ := strings.Cut(strings.TrimSpace(update.Message.CommandArguments()), " ")
+
+		articleID, err := strconv.ParseInt(idArg, 10, 64)
+		if err != nil {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, resummarizeUsage))
+			return err
+		}
+
+		summary, err := resummarizer.Resummarize(ctx, articleID, strings.TrimSpace(promt))
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Статья %d не найдена", articleID)))
+			return err
+		}
+
+		msgText := fmt.Sprintf("Новое summary статьи %d:\n\n%s", articleID, summary)
+		if summary == "" {
+			msgText = fmt.Sprintf("Summarizer вернул пустое summary для статьи %d", articleID)
+		}
+
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, msgText)); err != nil {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"strconv"
+	"strings"
+)
+
+type ArticleRetrier interface {
+	Retry(ctx context.Context, id int64) error
+}
+
+// Возвращает статью из dead-letter, пропущенную фильтрами или снятую с модерации обратно в очередь на отправку
+func ViewCmdRetry(retrier ArticleRetrier) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		articleID, err := strconv.ParseInt(strings.TrimSpace(update.Message.CommandArguments()), 10, 64)
+		if err != nil {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "Использование: /retry ID"))
+			return err
+		}
+
+		msgText := fmt.Sprintf("Статья %d возвращена в очередь на отправку", articleID)
+		if err := retrier.Retry(ctx, articleID); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			msgText = fmt.Sprintf("Статья %d не найдена, уже опубликована или ждет решения редакторов", articleID)
+		}
+
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, msgText)); err != nil {
+			return err
+		}
+		return nil
+	}
+}
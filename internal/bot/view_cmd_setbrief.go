@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/samber/lo"
+	"strconv"
+	"strings"
+)
+
+type BriefSetter interface {
+	SetBrief(ctx context.Context, brief model.Brief) error
+}
+
+const setBriefUsage = `Использование: /setbrief DESTINATION [ПОРОГ] БРИФ
+Например: /setbrief telegram 60 backend разработка, Go, базы данных; без крипто-хайпа
+Статьи с оценкой релевантности брифу ниже порога (0-100) не публикуются в это место назначения`
+
+// Задает редакционный бриф места назначения. Если порог не указан, используется defaultThreshold
+func ViewCmdSetBrief(setter BriefSetter, destinations []string, defaultThreshold int) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		destination, text, _ := strings.Cut(strings.TrimSpace(update.Message.CommandArguments()), " ")
+		text = strings.TrimSpace(text)
+
+		threshold := defaultThreshold
+		if first, rest, ok := strings.Cut(text, " "); ok {
+			if value, err := strconv.Atoi(first); err == nil {
+				threshold, text = value, strings.TrimSpace(rest)
+			}
+		}
+
+		if destination == "" || text == "" || threshold < 0 || threshold > 100 {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, setBriefUsage))
+			return err
+		}
+
+		if !lo.Contains(destinations, destination) {
+			msgText := fmt.Sprintf("Неизвестное место назначения %s, доступны: %s", destination, strings.Join(destinations, ", "))
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, msgText))
+			return err
+		}
+
+		if err := setter.SetBrief(ctx, model.Brief{Destination: destination, Text: text, Threshold: threshold}); err != nil {
+			return err
+		}
+
+		msgText := fmt.Sprintf("Бриф для %s сохранен, порог релевантности %d", destination, threshold)
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, msgText)); err != nil {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/page"
+	"strings"
+)
+
+type ExtractionRuleSetter interface {
+	SetRule(ctx context.Context, rule model.ExtractionRule) error
+}
+
+const setExtractionUsage = `Использование: /setextraction DOMAIN {"selector": "...", "strip": ["...", "..."], "paywall": "..."}
+Например: /setextraction example.com {"selector": "article .content", "strip": [".cookie-banner", ".subscribe"], "paywall": ".paywall"}
+selector - CSS селектор блока с текстом статьи, strip - элементы, которые удаляются перед извлечением текста,
+paywall - элемент, который есть на странице только у платных статей. Правило действует и на поддомены`
+
+// Задает правило извлечения текста статей для домена, заменяя прежнее
+func ViewCmdSetExtraction(setter ExtractionRuleSetter) botkit.ViewFunc {
+	type setExtractionArgs struct {
+		Selector string   `json:"selector"`
+		Strip    []string `json:"strip"`
+		Paywall  string   `json:"paywall"`
+	}
+
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		domain, rawArgs, _ := strings.Cut(strings.TrimSpace(update.Message.CommandArguments()), " ")
+		domain = page.NormalizeDomain(domain)
+
+		args, err := botkit.ParseJSON[setExtractionArgs](rawArgs)
+		if err != nil || domain == "" || (args.Selector == "" && len(args.Strip) == 0 && args.Paywall == "") {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, setExtractionUsage))
+			return err
+		}
+
+		rule := model.ExtractionRule{
+			Domain:          domain,
+			ContentSelector: strings.TrimSpace(args.Selector),
+			StripSelectors:  args.Strip,
+			PaywallSelector: strings.TrimSpace(args.Paywall),
+		}
+
+		if err := page.ValidateRule(rule); err != nil {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Ошибка в селекторе: %v", err)))
+			return err
+		}
+
+		if err := setter.SetRule(ctx, rule); err != nil {
+			return err
+		}
+
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Правило для %s сохранено", domain))); err != nil {
+			return err
+		}
+		return nil
+	}
+}
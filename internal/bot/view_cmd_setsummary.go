@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/summary"
+	"strconv"
+	"strings"
+)
+
+type SummarySettingsStorage interface {
+	Settings(ctx context.Context, sourceID int64) (model.SummarySettings, error)
+	SaveSettings(ctx context.Context, settings model.SummarySettings) error
+}
+
+const setSummaryUsage = `Использование: /setsummary SOURCE_ID {"enabled": true, "prompt": "...", "max_length": 500, "language": "ru"}
+Можно передать только те поля, которые нужно изменить. В промпте доступны переменные {{ .Title }}, {{ .Source }}, {{ .Language }} и {{ .InputLanguage }} - язык статьи`
+
+// Меняет настройки генерации summary для источника. Поля, которых нет в JSON, остаются прежними
+func ViewCmdSetSummary(sources SourceGetter, storage SummarySettingsStorage) botkit.ViewFunc {
+	type setSummaryArgs struct {
+		Enabled   *bool   `json:"enabled"`
+		Prompt    *string `json:"prompt"`
+		MaxLength *int    `json:"max_length"`
+		Language  *string `json:"language"`
+	}
+
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		idArg, rawArgs, _ := strings.Cut(strings.TrimSpace(update.Message.CommandArguments()), " ")
+
+		sourceID, err := strconv.ParseInt(idArg, 10, 64)
+		if err != nil {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, setSummaryUsage))
+			return err
+		}
+
+		args, err := botkit.ParseJSON[setSummaryArgs](rawArgs)
+		if err != nil {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, setSummaryUsage))
+			return err
+		}
+
+		if _, err := sources.SourceByID(ctx, sourceID); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Источник %d не найден", sourceID)))
+			return err
+		}
+
+		settings, err := storage.Settings(ctx, sourceID)
+		if err != nil {
+			return err
+		}
+
+		if args.Enabled != nil {
+			settings.Enabled = *args.Enabled
+		}
+		if args.Prompt != nil {
+			settings.PromptTemplate = strings.TrimSpace(*args.Prompt)
+		}
+		if args.MaxLength != nil {
+			settings.MaxLength = *args.MaxLength
+		}
+		if args.Language != nil {
+			settings.Language = strings.TrimSpace(*args.Language)
+		}
+
+		if settings.MaxLength < 0 {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "max_length не может быть отрицательным"))
+			return err
+		}
+
+		if err := summary.ValidatePrompt(settings.PromptTemplate); err != nil {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Ошибка в шаблоне промпта: %v", err)))
+			return err
+		}
+
+		if err := storage.SaveSettings(ctx, settings); err != nil {
+			return err
+		}
+
+		return sendSummarySettings(bot, update.Message.Chat.ID, settings)
+	}
+}
@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"strconv"
+	"strings"
+)
+
+// Показывает настройки генерации summary для источника
+func ViewCmdSummarySettings(sources SourceGetter, storage SummarySettingsStorage) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		sourceID, err := strconv.ParseInt(strings.TrimSpace(update.Message.CommandArguments()), 10, 64)
+		if err != nil {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "Использование: /summarysettings SOURCE_ID"))
+			return err
+		}
+
+		if _, err := sources.SourceByID(ctx, sourceID); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Источник %d не найден", sourceID)))
+			return err
+		}
+
+		settings, err := storage.Settings(ctx, sourceID)
+		if err != nil {
+			return err
+		}
+
+		return sendSummarySettings(bot, update.Message.Chat.ID, settings)
+	}
+}
+
+func sendSummarySettings(bot *tgbotapi.BotAPI, chatID int64, settings model.SummarySettings) error {
+	enabled := "включено"
+	if !settings.Enabled {
+		enabled = "выключено"
+	}
+
+	prompt := settings.PromptTemplate
+	if prompt == "" {
+		prompt = "по умолчанию"
+	}
+
+	maxLength := "без ограничения"
+	if settings.MaxLength > 0 {
+		maxLength = strconv.Itoa(settings.MaxLength)
+	}
+
+	language := settings.Language
+	if language == "" {
+		language = "не задан"
+	}
+
+	msg := markup.New().
+		Bold(fmt.Sprintf("Summary для источника %d: %s", settings.SourceID, enabled)).
+		Line().Text("Промпт: ").Code(prompt).
+		Line().Text("Максимальная длина: " + maxLength).
+		Line().Text("Язык: " + language)
+
+	reply := tgbotapi.NewMessage(chatID, msg.Render(markup.MarkdownV2))
+	reply.ParseMode = markup.MarkdownV2.String()
+
+	_, err := bot.Send(reply)
+	return err
+}
@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"log"
+	"strconv"
+	"strings"
+)
+
+type ArticleUnposter interface {
+	Deliveries(ctx context.Context, articleID int64) ([]model.Delivery, error)
+	Unpost(ctx context.Context, id int64, requeue bool) error
+}
+
+const unpostUsage = "Использование: /unpost ID requeue - удалить пост и вернуть статью в очередь, /unpost ID drop - удалить пост и больше не публиковать статью"
+
+// Удаляет пост со статьей из канала. Статья возвращается в очередь или снимается с публикации насовсем
+func ViewCmdUnpost(unposter ArticleUnposter) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		args := strings.Fields(update.Message.CommandArguments())
+		if len(args) != 2 || (args[1] != "requeue" && args[1] != "drop") {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, unpostUsage))
+			return err
+		}
+
+		articleID, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, unpostUsage))
+			return err
+		}
+
+		deliveries, err := unposter.Deliveries(ctx, articleID)
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			// Удалить можно только сообщения в телеграме, из остальных мест назначения пост убирается вручную
+			if delivery.ChatID == 0 {
+				continue
+			}
+
+			// Сообщение могли уже удалить руками, поэтому ошибку удаления только логируем
+			if _, err := bot.Request(tgbotapi.NewDeleteMessage(delivery.ChatID, delivery.MessageID)); err != nil {
+				log.Printf("[ERROR] failed to delete message %d of article %d: %v", delivery.MessageID, articleID, err)
+			}
+		}
+
+		requeue := args[1] == "requeue"
+
+		msgText := fmt.Sprintf("Статья %d снята с публикации", articleID)
+		if requeue {
+			msgText = fmt.Sprintf("Статья %d снята с публикации и возвращена в очередь", articleID)
+		}
+
+		if err := unposter.Unpost(ctx, articleID, requeue); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			msgText = fmt.Sprintf("Статья %d не найдена", articleID)
+		}
+
+		if _, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, msgText)); err != nil {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"time"
+)
+
+// За сколько последних дней показывать расход по дням
+const usageDays = 7
+
+type UsageReporter interface {
+	UsageByDay(ctx context.Context, since time.Time) ([]model.UsageTotal, error)
+	UsageBySource(ctx context.Context, since time.Time) ([]model.UsageTotal, error)
+}
+
+// Показывает расход токенов LLM и его стоимость по дням и по источникам за текущий месяц.
+// Нулевой лимит означает, что ограничения нет
+func ViewCmdUsage(reporter UsageReporter, dailyBudget float64, monthlyBudget float64) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		now := time.Now().UTC()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+		byDay, err := reporter.UsageByDay(ctx, today.AddDate(0, 0, -(usageDays-1)))
+		if err != nil {
+			return err
+		}
+
+		bySource, err := reporter.UsageBySource(ctx, monthStart)
+		if err != nil {
+			return err
+		}
+
+		var monthSpent float64
+		for _, total := range bySource {
+			monthSpent += total.Cost
+		}
+
+		msg := markup.New().
+			Bold(fmt.Sprintf("Расход за %d дней", usageDays)).Line()
+		if len(byDay) == 0 {
+			msg.Text("Запросов не было").Line()
+		}
+		for _, total := range byDay {
+			msg.Add(formatUsage(total)...).Line()
+		}
+
+		msg.Line().Bold("По источникам за месяц").Line()
+		if len(bySource) == 0 {
+			msg.Text("Запросов не было").Line()
+		}
+		for _, total := range bySource {
+			msg.Add(formatUsage(total)...).Line()
+		}
+
+		msg.Line().Text(fmt.Sprintf("Потрачено за месяц: $%.4f", monthSpent))
+		if monthlyBudget > 0 {
+			msg.Text(fmt.Sprintf(" из $%.2f", monthlyBudget))
+		}
+		if dailyBudget > 0 {
+			msg.Line().Text(fmt.Sprintf("Дневной лимит: $%.2f", dailyBudget))
+		}
+
+		for _, part := range msg.Split(markup.MaxMessageLen) {
+			reply := tgbotapi.NewMessage(update.Message.Chat.ID, part.Render(markup.MarkdownV2))
+			reply.ParseMode = markup.MarkdownV2.String()
+
+			if _, err := bot.Send(reply); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func formatUsage(total model.UsageTotal) []markup.Node {
+	return []markup.Node{
+		markup.Code(total.Key),
+		markup.Text(fmt.Sprintf(
+			" — $%.4f, запросов: %d, токенов: %d + %d",
+			total.Cost,
+			total.Requests,
+			total.PromptTokens,
+			total.CompletionTokens,
+		)),
+	}
+}
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"strconv"
+	"strings"
+)
+
+type RelevanceExplainer interface {
+	Briefs(ctx context.Context) ([]model.Brief, error)
+	Scores(ctx context.Context, articleID int64) ([]model.RelevanceScore, error)
+}
+
+// Объясняет, почему статья попала или не попала в места назначения: язык, теги, оценки релевантности и причина пропуска
+func ViewCmdWhy(articles ArticleGetter, relevance RelevanceExplainer) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		articleID, err := strconv.ParseInt(strings.TrimSpace(update.Message.CommandArguments()), 10, 64)
+		if err != nil {
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "Использование: /why ID"))
+			return err
+		}
+
+		article, err := articles.ArticleByID(ctx, articleID)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			_, err := bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("Статья %d не найдена", articleID)))
+			return err
+		}
+
+		briefs, err := relevance.Briefs(ctx)
+		if err != nil {
+			return err
+		}
+
+		scores, err := relevance.Scores(ctx, articleID)
+		if err != nil {
+			return err
+		}
+
+		msg := markup.New().
+			Bold(article.Title).
+			Line().Text("Язык: " + valueOr(article.Language, "не определен")).
+			Line().Text("Теги: " + valueOr(strings.Join(article.Tags, ", "), "нет"))
+
+		if article.Paywalled {
+			msg.Line().Text("Полный текст недоступен (paywall), summary не генерируется")
+		}
+
+		if !article.SkippedAt.IsZero() {
+			msg.Line().Text("Пропущена: ").Code(article.SkipReason)
+		}
+
+		// Порог берем из текущего брифа: с ним статья сравнивается при отправке
+		thresholds := make(map[string]int, len(briefs))
+		for _, brief := range briefs {
+			thresholds[brief.Destination] = brief.Threshold
+		}
+
+		msg.Line().Line().Bold("Оценки релевантности")
+		if len(scores) == 0 {
+			msg.Line().Text("Статья не оценивалась")
+		}
+
+		for _, score := range scores {
+			threshold, ok := thresholds[score.Destination]
+
+			verdict := "бриф удален"
+			if ok {
+				verdict = fmt.Sprintf("порог %d, проходит", threshold)
+				if score.Score < threshold {
+					verdict = fmt.Sprintf("порог %d, не проходит", threshold)
+				}
+			}
+
+			msg.Line().
+				Code(score.Destination).Text(fmt.Sprintf(": %d (%s)", score.Score, verdict)).
+				Line().Text(score.Reason)
+		}
+
+		for _, part := range msg.Split(markup.MaxMessageLen) {
+			reply := tgbotapi.NewMessage(update.Message.Chat.ID, part.Render(markup.MarkdownV2))
+			reply.ParseMode = markup.MarkdownV2.String()
+
+			if _, err := bot.Send(reply); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func valueOr(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
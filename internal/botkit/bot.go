@@ -5,6 +5,8 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"log"
 	"runtime/debug"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -13,8 +15,17 @@ type Bot struct {
 	api *tgbotapi.BotAPI
 	// Мапа в которой будем хранить view
 	cmdViews map[string]ViewFunc
+	// Мапа view для нажатий на inline кнопки, ключ - префикс данных кнопки
+	callbackViews map[string]ViewFunc
+	// Таймауты команд, которым не хватает стандартного updateTimeout
+	cmdTimeouts map[string]time.Duration
+	// Выполняющиеся долгие команды, Run дожидается их при остановке
+	longCmds sync.WaitGroup
 }
 
+// Сколько времени по умолчанию дается view на обработку одного update
+const updateTimeout = 5 * time.Second
+
 // addsource
 // listsources
 // deletesource
@@ -42,6 +53,40 @@ func (b *Bot) RegisterCmdView(cmd string, view ViewFunc) {
 	b.cmdViews[cmd] = view
 }
 
+// Задает свой таймаут для команды cmd, например для команд, которые обращаются к LLM.
+// Такие команды выполняются в отдельной горутине, чтобы остальные команды и нажатия на кнопки не ждали их
+func (b *Bot) SetCmdTimeout(cmd string, timeout time.Duration) {
+	if b.cmdTimeouts == nil {
+		b.cmdTimeouts = make(map[string]time.Duration)
+	}
+
+	b.cmdTimeouts[cmd] = timeout
+}
+
+// Метод для регистрации View для нажатий на inline кнопки.
+// Данные кнопки должны быть собраны через CallbackData с тем же префиксом
+func (b *Bot) RegisterCallbackView(prefix string, view ViewFunc) {
+	if b.callbackViews == nil {
+		b.callbackViews = make(map[string]ViewFunc)
+	}
+
+	b.callbackViews[prefix] = view
+}
+
+// Собирает данные для inline кнопки в формате prefix:arg1:arg2.
+// Телеграм ограничивает данные кнопки 64 байтами, поэтому аргументы должны быть короткими
+func CallbackData(prefix string, args ...string) string {
+	return strings.Join(append([]string{prefix}, args...), callbackSeparator)
+}
+
+// Разбирает данные нажатой inline кнопки, возвращая аргументы без префикса
+func CallbackArgs(update tgbotapi.Update) []string {
+	parts := strings.Split(update.CallbackQuery.Data, callbackSeparator)
+	return parts[1:]
+}
+
+const callbackSeparator = ":"
+
 func (b *Bot) Run(ctx context.Context) error {
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
@@ -51,10 +96,9 @@ func (b *Bot) Run(ctx context.Context) error {
 	for {
 		select {
 		case update := <-updates:
-			updateCtx, updateCancel := context.WithTimeout(ctx, 5*time.Second)
-			b.handleUpdate(updateCtx, update)
-			updateCancel()
+			b.handleUpdate(ctx, update)
 		case <-ctx.Done():
+			b.longCmds.Wait()
 			return ctx.Err()
 		}
 	}
@@ -62,12 +106,15 @@ func (b *Bot) Run(ctx context.Context) error {
 
 // Метод, который обрабатывает update и роутит команды на сооветствующие view
 func (b *Bot) handleUpdate(ctx context.Context, update tgbotapi.Update) {
-	// В процессе работы бота в каких то view может произойти паника, поэтому мы ее должны перехватить
-	defer func() {
-		if p := recover(); p != nil {
-			log.Printf("[ERROR] panic recovered: %v\n%s", p, string(debug.Stack()))
-		}
-	}()
+	defer recoverPanic()
+
+	if update.CallbackQuery != nil {
+		ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+		defer cancel()
+
+		b.handleCallback(ctx, update)
+		return
+	}
 
 	if update.Message == nil || !update.Message.IsCommand() {
 		return
@@ -92,6 +139,33 @@ func (b *Bot) handleUpdate(ctx context.Context, update tgbotapi.Update) {
 
 	view = cmdView
 
+	timeout, long := b.cmdTimeouts[cmd]
+	if !long {
+		b.runCmdView(ctx, view, update, updateTimeout)
+		return
+	}
+
+	b.longCmds.Add(1)
+	go func() {
+		defer b.longCmds.Done()
+		defer recoverPanic()
+
+		b.runCmdView(ctx, view, update, timeout)
+	}()
+}
+
+// В процессе работы бота в каких то view может произойти паника, поэтому мы ее должны перехватить
+func recoverPanic() {
+	if p := recover(); p != nil {
+		log.Printf("[ERROR] panic recovered: %v\n%s", p, string(debug.Stack()))
+	}
+}
+
+// Вызывает view команды с таймаутом timeout
+func (b *Bot) runCmdView(ctx context.Context, view ViewFunc, update tgbotapi.Update, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	// Вызываем view и обрабатываем ошибку от нее если та вернула ошибку
 	if err := view(ctx, b.api, update); err != nil {
 		log.Printf("[ERROR] failed to handle update: %v", err)
@@ -103,3 +177,24 @@ func (b *Bot) handleUpdate(ctx context.Context, update tgbotapi.Update) {
 		}
 	}
 }
+
+// Метод, который роутит нажатия на inline кнопки на соответствующие view по префиксу данных кнопки
+func (b *Bot) handleCallback(ctx context.Context, update tgbotapi.Update) {
+	prefix, _, _ := strings.Cut(update.CallbackQuery.Data, callbackSeparator)
+
+	view, ok := b.callbackViews[prefix]
+	if !ok {
+		return
+	}
+
+	if err := view(ctx, b.api, update); err != nil {
+		log.Printf("[ERROR] failed to handle callback: %v", err)
+
+		// Телеграм ждет ответа на нажатие, иначе у пользователя будет крутиться индикатор загрузки
+		if _, err := b.api.Request(
+			tgbotapi.NewCallback(update.CallbackQuery.ID, "internal error"),
+		); err != nil {
+			log.Printf("[ERROR] failed to answer callback: %v", err)
+		}
+	}
+}
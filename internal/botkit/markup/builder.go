@@ -0,0 +1,454 @@
+package markup
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Максимальная длина текста сообщения в телеграме (в UTF-16 символах после разбора разметки)
+const MaxMessageLen = 4096
+
+// Максимальная длина подписи к фото или документу в телеграме
+const MaxCaptionLen = 1024
+
+// Режим разметки сообщения телеграма
+type Mode int
+
+const (
+	MarkdownV2 Mode = iota
+	HTML
+)
+
+// Разбирает режим разметки из строки, например из конфига
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(s) {
+	case "", "markdownv2":
+		return MarkdownV2, nil
+	case "html":
+		return HTML, nil
+	default:
+		return 0, fmt.Errorf("unknown parse mode %q", s)
+	}
+}
+
+// Значение для поля ParseMode сообщения телеграма
+func (m Mode) String() string {
+	if m == HTML {
+		return "HTML"
+	}
+	return "MarkdownV2"
+}
+
+// Экранирует обычный текст для режима разметки
+func (m Mode) Escape(s string) string {
+	if m == HTML {
+		return htmlReplacer.Replace(s)
+	}
+	return EscapeForMarkdown(s)
+}
+
+var (
+	htmlReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+	// Внутри `code` в MarkdownV2 нужно экранировать только обратную кавычку и обратный слэш
+	codeReplacer = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+	// Внутри (...) ссылки в MarkdownV2 нужно экранировать только закрывающую скобку и обратный слэш
+	linkURLReplacer = strings.NewReplacer("\\", "\\\\", ")", "\\)")
+)
+
+// Элемент сообщения
+type Node interface {
+	// Записывает элемент в sb в нужном режиме разметки
+	render(m Mode, sb *strings.Builder)
+	// Видимый текст элемента, без разметки
+	plain() string
+	// Возвращает элемент, видимый текст которого не длиннее n UTF-16 символов
+	cut(n int) Node
+}
+
+// Обычный текст
+func Text(s string) Node {
+	return textNode(s)
+}
+
+// Жирный текст
+func Bold(children ...Node) Node {
+	return styleNode{md: "*", tag: "b", children: children}
+}
+
+// Курсив
+func Italic(children ...Node) Node {
+	return styleNode{md: "_", tag: "i", children: children}
+}
+
+// Скрытый текст, который открывается по нажатию
+func Spoiler(children ...Node) Node {
+	return styleNode{md: "||", tag: "tg-spoiler", children: children}
+}
+
+// Ссылка
+func Link(url string, children ...Node) Node {
+	return linkNode{url: url, children: children}
+}
+
+// Моноширинный фрагмент
+func Code(s string) Node {
+	return codeNode(s)
+}
+
+// Цитата. Должна начинаться с новой строки
+func Blockquote(children ...Node) Node {
+	return quoteNode{children: children}
+}
+
+type textNode string
+
+func (t textNode) render(m Mode, sb *strings.Builder) {
+	sb.WriteString(m.Escape(string(t)))
+}
+
+func (t textNode) plain() string {
+	return string(t)
+}
+
+func (t textNode) cut(n int) Node {
+	return textNode(cutUTF16(string(t), n))
+}
+
+type styleNode struct {
+	md       string
+	tag      string
+	children []Node
+}
+
+func (s styleNode) render(m Mode, sb *strings.Builder) {
+	if m == HTML {
+		sb.WriteString("<" + s.tag + ">")
+		renderNodes(m, sb, s.children)
+		sb.WriteString("</" + s.tag + ">")
+		return
+	}
+
+	sb.WriteString(s.md)
+	renderNodes(m, sb, s.children)
+	sb.WriteString(s.md)
+}
+
+func (s styleNode) plain() string {
+	return plainNodes(s.children)
+}
+
+func (s styleNode) cut(n int) Node {
+	return styleNode{md: s.md, tag: s.tag, children: cutNodes(s.children, n)}
+}
+
+type linkNode struct {
+	url      string
+	children []Node
+}
+
+func (l linkNode) render(m Mode, sb *strings.Builder) {
+	if m == HTML {
+		sb.WriteString(`<a href="` + htmlReplacer.Replace(l.url) + `">`)
+		renderNodes(m, sb, l.children)
+		sb.WriteString("</a>")
+		return
+	}
+
+	sb.WriteString("[")
+	renderNodes(m, sb, l.children)
+	sb.WriteString("](" + linkURLReplacer.Replace(l.url) + ")")
+}
+
+func (l linkNode) plain() string {
+	return plainNodes(l.children)
+}
+
+func (l linkNode) cut(n int) Node {
+	return linkNode{url: l.url, children: cutNodes(l.children, n)}
+}
+
+type codeNode string
+
+func (c codeNode) render(m Mode, sb *strings.Builder) {
+	if m == HTML {
+		sb.WriteString("<code>" + htmlReplacer.Replace(string(c)) + "</code>")
+		return
+	}
+
+	sb.WriteString("`" + codeReplacer.Replace(string(c)) + "`")
+}
+
+func (c codeNode) plain() string {
+	return string(c)
+}
+
+func (c codeNode) cut(n int) Node {
+	return codeNode(cutUTF16(string(c), n))
+}
+
+type quoteNode struct {
+	children []Node
+}
+
+func (q quoteNode) render(m Mode, sb *strings.Builder) {
+	if m == HTML {
+		sb.WriteString("<blockquote>")
+		renderNodes(m, sb, q.children)
+		sb.WriteString("</blockquote>")
+		return
+	}
+
+	// В MarkdownV2 каждая строка цитаты начинается с >
+	var inner strings.Builder
+	renderNodes(m, &inner, q.children)
+	sb.WriteString(">" + strings.ReplaceAll(inner.String(), "\n", "\n>"))
+}
+
+func (q quoteNode) plain() string {
+	return plainNodes(q.children)
+}
+
+func (q quoteNode) cut(n int) Node {
+	return quoteNode{children: cutNodes(q.children, n)}
+}
+
+func renderNodes(m Mode, sb *strings.Builder, nodes []Node) {
+	for _, node := range nodes {
+		node.render(m, sb)
+	}
+}
+
+func plainNodes(nodes []Node) string {
+	var sb strings.Builder
+	for _, node := range nodes {
+		sb.WriteString(node.plain())
+	}
+	return sb.String()
+}
+
+// Оставляет от списка элементов первые n UTF-16 символов видимого текста
+func cutNodes(nodes []Node, n int) []Node {
+	var result []Node
+
+	for _, node := range nodes {
+		if n <= 0 {
+			break
+		}
+
+		length := utf16Len(node.plain())
+		if length <= n {
+			result = append(result, node)
+			n -= length
+			continue
+		}
+
+		result = append(result, node.cut(n))
+		break
+	}
+
+	return result
+}
+
+// Сборщик сообщения из элементов разметки.
+// Текст экранируется при рендере в зависимости от режима, поэтому в элементы передается обычный текст
+type Builder struct {
+	nodes []Node
+}
+
+func New(nodes ...Node) *Builder {
+	return &Builder{nodes: nodes}
+}
+
+// Добавляет элементы в конец сообщения
+func (b *Builder) Add(nodes ...Node) *Builder {
+	b.nodes = append(b.nodes, nodes...)
+	return b
+}
+
+func (b *Builder) Text(s string) *Builder {
+	return b.Add(Text(s))
+}
+
+func (b *Builder) Bold(s string) *Builder {
+	return b.Add(Bold(Text(s)))
+}
+
+func (b *Builder) Italic(s string) *Builder {
+	return b.Add(Italic(Text(s)))
+}
+
+func (b *Builder) Spoiler(s string) *Builder {
+	return b.Add(Spoiler(Text(s)))
+}
+
+func (b *Builder) Code(s string) *Builder {
+	return b.Add(Code(s))
+}
+
+func (b *Builder) Link(text string, url string) *Builder {
+	return b.Add(Link(url, Text(text)))
+}
+
+func (b *Builder) Blockquote(s string) *Builder {
+	return b.Add(Blockquote(Text(s)))
+}
+
+// Перевод строки
+func (b *Builder) Line() *Builder {
+	return b.Add(Text("\n"))
+}
+
+// Рендерит сообщение в выбранном режиме разметки
+func (b *Builder) Render(m Mode) string {
+	var sb strings.Builder
+	renderNodes(m, &sb, b.nodes)
+	return sb.String()
+}
+
+// Длина видимого текста сообщения в UTF-16 символах. Именно ее телеграм сравнивает с MaxMessageLen
+func (b *Builder) Len() int {
+	return utf16Len(plainNodes(b.nodes))
+}
+
+// Возвращает сообщение, видимый текст которого не длиннее max.
+// Если текст пришлось обрезать, в конец добавляется многоточие. Разметка при этом остается корректной
+func (b *Builder) Truncate(max int) *Builder {
+	if b.Len() <= max {
+		return New(b.nodes...)
+	}
+
+	if max < 1 {
+		return New()
+	}
+
+	return New(cutNodes(b.nodes, max-1)...).Text("…")
+}
+
+// Разбивает сообщение на части, каждая из которых не длиннее max.
+// Части разбиваются по границам элементов, а слишком длинный обычный текст делится по строкам и пробелам
+func (b *Builder) Split(max int) []*Builder {
+	if max < 1 {
+		return []*Builder{New(b.nodes...)}
+	}
+
+	var (
+		parts   []*Builder
+		current = New()
+	)
+
+	flush := func() {
+		if len(current.nodes) > 0 {
+			parts = append(parts, current)
+		}
+		current = New()
+	}
+
+	for _, node := range b.nodes {
+		length := utf16Len(node.plain())
+
+		switch {
+		case current.Len()+length <= max:
+			current.Add(node)
+		case length <= max:
+			flush()
+			current.Add(node)
+		default:
+			flush()
+
+			text, ok := node.(textNode)
+			if !ok {
+				// Форматированный элемент не делим, а обрезаем, чтобы не сломать разметку
+				current.Add(New(node).Truncate(max).nodes...)
+				continue
+			}
+
+			for _, piece := range splitText(string(text), max) {
+				flush()
+				current.Text(piece)
+			}
+		}
+	}
+
+	flush()
+	return parts
+}
+
+// Делит текст на куски не длиннее max, стараясь резать по переводам строк и пробелам
+func splitText(s string, max int) []string {
+	var pieces []string
+
+	for utf16Len(s) > max {
+		prefix := cutUTF16(s, max)
+		// Символ вне BMP занимает две единицы UTF-16 и может не влезть в max целиком.
+		// Тогда берем его одного, иначе s не уменьшается и цикл не закончится
+		if prefix == "" {
+			_, size := utf8.DecodeRuneInString(s)
+			prefix = s[:size]
+		}
+
+		// Разделитель сразу после префикса тоже подходит: тогда префикс уходит в кусок целиком
+		window := prefix
+		if len(prefix) < len(s) {
+			window = s[:len(prefix)+1]
+		}
+
+		idx := strings.LastIndex(window, "\n")
+		if idx <= 0 {
+			idx = strings.LastIndex(window, " ")
+		}
+
+		if idx <= 0 {
+			pieces = append(pieces, prefix)
+			s = s[len(prefix):]
+			continue
+		}
+
+		pieces = append(pieces, s[:idx])
+		s = s[idx+1:]
+	}
+
+	return append(pieces, s)
+}
+
+// Длина строки в UTF-16 символах, как ее считает телеграм
+func Len(s string) int {
+	return utf16Len(s)
+}
+
+// Обрезает обычный текст до max UTF-16 символов. Если текст пришлось обрезать, в конец добавляется многоточие
+func Truncate(s string, max int) string {
+	if utf16Len(s) <= max {
+		return s
+	}
+
+	if max < 1 {
+		return ""
+	}
+
+	return strings.TrimSpace(cutUTF16(s, max-1)) + "…"
+}
+
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// Возвращает самый длинный префикс s, который занимает не больше n UTF-16 символов
+func cutUTF16(s string, n int) string {
+	length := 0
+
+	for i, r := range s {
+		size := 1
+		if r >= 0x10000 {
+			size = 2
+		}
+
+		if length+size > n {
+			return s[:i]
+		}
+		length += size
+	}
+
+	return s
+}
@@ -0,0 +1,276 @@
+package markup
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCutUTF16(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{s: "abc", n: 2, want: "ab"},
+		{s: "abc", n: 5, want: "abc"},
+		{s: "", n: 3, want: ""},
+		{s: "привет", n: 3, want: "при"},
+		// Суррогатная пара не разрезается пополам
+		{s: "a😀b", n: 2, want: "a"},
+		{s: "a😀b", n: 3, want: "a😀"},
+		{s: "😀", n: 1, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := cutUTF16(tt.s, tt.n); got != tt.want {
+			t.Errorf("cutUTF16(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{s: "короткий", max: 10, want: "короткий"},
+		{s: "hello world", max: 7, want: "hello…"},
+		{s: "hello world", max: 0, want: ""},
+		{s: "hello", max: 1, want: "…"},
+		{s: "a😀b", max: 3, want: "a…"},
+		{s: "😀😀", max: 3, want: "😀…"},
+	}
+
+	for _, tt := range tests {
+		got := Truncate(tt.s, tt.max)
+		if got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.want)
+		}
+		if Len(got) > tt.max && tt.max > 0 {
+			t.Errorf("Truncate(%q, %d) is %d UTF-16 characters", tt.s, tt.max, Len(got))
+		}
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		s    string
+		max  int
+		want []string
+	}{
+		{s: "one two three", max: 7, want: []string{"one two", "three"}},
+		{s: "строка 1\nстрока 2", max: 10, want: []string{"строка 1", "строка 2"}},
+		{s: "abcdefgh", max: 3, want: []string{"abc", "def", "gh"}},
+		{s: "😀😀😀", max: 3, want: []string{"😀", "😀", "😀"}},
+		// Символ вне BMP не помещается в max целиком, но текст все равно делится до конца
+		{s: "😀a", max: 1, want: []string{"😀", "a"}},
+	}
+
+	for _, tt := range tests {
+		got := splitText(tt.s, tt.max)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("splitText(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestBuilderSplitAndTruncate(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder func() *Builder
+		max     int
+	}{
+		{
+			name: "entity spanning the split",
+			builder: func() *Builder {
+				return New(Text("Начало "), Bold(Text("жирный текст")), Text(" конец."))
+			},
+			max: 10,
+		},
+		{
+			name: "nested styles",
+			builder: func() *Builder {
+				return New(
+					Bold(Text("a "), Italic(Text("b-c "), Link("https://example.com/a_(b)", Text("ссылка с _подчеркиванием_")))),
+					Text(" хвост. "),
+					Spoiler(Text("спойлер!")),
+				)
+			},
+			max: 8,
+		},
+		{
+			name: "surrogate pairs at the cut",
+			builder: func() *Builder {
+				return New(Text(strings.Repeat("😀", 5)), Bold(Text("😀😀😀")), Text("a😀"))
+			},
+			max: 5,
+		},
+		{
+			name: "code and quote",
+			builder: func() *Builder {
+				return New(Code("a`b\\c <d>"), Text("\n"), Blockquote(Text("строка 1\nстрока 2 & еще")))
+			},
+			max: 6,
+		},
+		{
+			name: "long plain text",
+			builder: func() *Builder {
+				return New().Text(strings.Repeat("слово. ", 20)).Bold("итог")
+			},
+			max: 30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := tt.builder().Split(tt.max)
+			if len(parts) == 0 {
+				t.Fatal("Split() returned no parts")
+			}
+			for i, part := range parts {
+				checkRendered(t, part, tt.max, "part", i)
+			}
+
+			truncated := tt.builder().Truncate(tt.max)
+			checkRendered(t, truncated, tt.max, "truncated", 0)
+			if !strings.HasSuffix(plainNodes(truncated.nodes), "…") {
+				t.Errorf("truncated text %q has no ellipsis", plainNodes(truncated.nodes))
+			}
+		})
+	}
+}
+
+// Проверяет, что сообщение корректно размечено в обоих режимах и его видимый текст не длиннее max
+func checkRendered(t *testing.T, b *Builder, max int, what string, i int) {
+	t.Helper()
+
+	want := plainNodes(b.nodes)
+	if b.Len() > max {
+		t.Errorf("%s %d %q is %d UTF-16 characters, want at most %d", what, i, want, b.Len(), max)
+	}
+
+	for _, m := range []Mode{MarkdownV2, HTML} {
+		rendered := b.Render(m)
+
+		var visible string
+		if m == HTML {
+			visible = visibleHTML(t, rendered)
+		} else {
+			visible = visibleMarkdown(t, rendered)
+		}
+
+		if visible != want {
+			t.Errorf("%s %d in %s shows %q, want %q (rendered %q)", what, i, m, visible, want, rendered)
+		}
+		if n := Len(visible); n > max {
+			t.Errorf("%s %d in %s is %d UTF-16 characters, want at most %d", what, i, m, n, max)
+		}
+	}
+}
+
+var htmlTag = regexp.MustCompile(`</?([a-z-]+)[^>]*>`)
+
+// Видимый текст HTML сообщения. Проверяет, что теги сбалансированы
+func visibleHTML(t *testing.T, s string) string {
+	t.Helper()
+
+	var stack []string
+	for _, match := range htmlTag.FindAllStringSubmatch(s, -1) {
+		if !strings.HasPrefix(match[0], "</") {
+			stack = append(stack, match[1])
+			continue
+		}
+		if len(stack) == 0 || stack[len(stack)-1] != match[1] {
+			t.Errorf("unbalanced tag %s in %q", match[0], s)
+			return ""
+		}
+		stack = stack[:len(stack)-1]
+	}
+	if len(stack) > 0 {
+		t.Errorf("unclosed tags %v in %q", stack, s)
+	}
+
+	text := htmlTag.ReplaceAllString(s, "")
+	if strings.ContainsAny(text, "<>") {
+		t.Errorf("unescaped angle bracket in %q", s)
+	}
+
+	return html.UnescapeString(text)
+}
+
+// Видимый текст MarkdownV2 сообщения. Проверяет, что спец символы экранированы, а разметка закрыта
+func visibleMarkdown(t *testing.T, s string) string {
+	t.Helper()
+
+	var (
+		sb     strings.Builder
+		open   = make(map[string]bool)
+		code   bool
+		links  int
+		runes  = []rune(s)
+		atLine = true
+	)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		line := atLine
+		atLine = r == '\n'
+
+		switch {
+		case r == '\\':
+			if i+1 == len(runes) {
+				t.Errorf("dangling backslash in %q", s)
+				return sb.String()
+			}
+			i++
+			sb.WriteRune(runes[i])
+		case code:
+			if r == '`' {
+				code = false
+				continue
+			}
+			sb.WriteRune(r)
+		case r == '`':
+			code = true
+		case r == '*' || r == '_' || r == '~':
+			open[string(r)] = !open[string(r)]
+		case r == '|' && i+1 < len(runes) && runes[i+1] == '|':
+			open["||"] = !open["||"]
+			i++
+		case r == '>' && line:
+		case r == '[':
+			links++
+		case r == ']':
+			if links == 0 || i+1 == len(runes) || runes[i+1] != '(' {
+				t.Errorf("unexpected ] in %q", s)
+				return sb.String()
+			}
+			links--
+
+			// Пропускаем URL до неэкранированной закрывающей скобки
+			for i += 2; i < len(runes) && runes[i] != ')'; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+		case strings.ContainsRune("[]()>#+-=|{}.!", r):
+			t.Errorf("unescaped %q in %q", r, s)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	if code || links > 0 {
+		t.Errorf("unclosed code or link in %q", s)
+	}
+	for marker, isOpen := range open {
+		if isOpen {
+			t.Errorf("unclosed %s in %q", marker, s)
+		}
+	}
+
+	return sb.String()
+}
@@ -4,6 +4,8 @@ import "strings"
 
 var (
 	replacer = strings.NewReplacer(
+		"\\",
+		"\\\\",
 		"-",
 		"\\-",
 		"_",
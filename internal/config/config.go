@@ -17,8 +17,118 @@ type Config struct {
 	FetchInterval        time.Duration `hcl:"fetch_interval" env:"FETCH_INTERVAL" default:"1m"`
 	NotificationInterval time.Duration `hcl:"notification_interval" env:"NOTIFICATION_INTERVAL" default:"1m"`
 	FilterKeywords       []string      `hcl:"filter_keywords" env:"FILTER_KEYWORDS"`
-	OpenAIKey            string        `hcl:"openai_key" env:"OPENAI_KEY"`
-	OpenAIPromt          string        `hcl:"openai_promt" env:"OPENAI_PROMT"`
+	// Языки, статьи на которых сохраняются, например ru,en. Пустой список - любые языки
+	FilterLanguages []string `hcl:"filter_languages" env:"FILTER_LANGUAGES"`
+	// Языки, которые принимает место назначения, в формате "место назначения:язык|язык", например telegram:ru|en.
+	// Места назначения, которых нет в списке, принимают статьи на любом языке
+	DestinationLanguages []string `hcl:"destination_languages" env:"DESTINATION_LANGUAGES"`
+	// Статьи с этими тегами из словаря не публикуются
+	FilterTags []string `hcl:"filter_tags" env:"FILTER_TAGS"`
+	// Теги, статьи с которыми принимает место назначения, в формате "место назначения:тег|тег", например telegram:go|databases
+	DestinationTags []string `hcl:"destination_tags" env:"DESTINATION_TAGS"`
+	OpenAIKey       string   `hcl:"openai_key" env:"OPENAI_KEY"`
+	OpenAIPromt     string   `hcl:"openai_promt" env:"OPENAI_PROMT"`
+	// Бэкенд для генерации summary: openai (в том числе совместимые self-hosted серверы), textrank (без сети) или none
+	SummarizerBackend string `hcl:"summarizer_backend" env:"SUMMARIZER_BACKEND" default:"openai"`
+	// Адрес OpenAI-совместимого API, например http://localhost:8000/v1. Пустое значение означает api.openai.com
+	OpenAIBaseURL     string  `hcl:"openai_base_url" env:"OPENAI_BASE_URL"`
+	OpenAIModel       string  `hcl:"openai_model" env:"OPENAI_MODEL" default:"gpt-3.5-turbo"`
+	OpenAITemperature float32 `hcl:"openai_temperature" env:"OPENAI_TEMPERATURE" default:"0.7"`
+	// Ограничение на длину ответа модели в токенах
+	OpenAIMaxTokens int `hcl:"openai_max_tokens" env:"OPENAI_MAX_TOKENS" default:"256"`
+	// Сколько токенов статьи отправляется модели в одном запросе. Более длинные статьи пересказываются по частям
+	OpenAIChunkTokens int `hcl:"openai_chunk_tokens" env:"OPENAI_CHUNK_TOKENS" default:"3000"`
+	// Сколько токенов статьи учитывается при генерации summary, остальное отбрасывается
+	SummaryMaxInputTokens int `hcl:"summary_max_input_tokens" env:"SUMMARY_MAX_INPUT_TOKENS" default:"12000"`
+	// Цены моделей в долларах за 1000 токенов в формате "модель:цена промпта:цена ответа"
+	OpenAIPrices []string `hcl:"openai_prices" env:"OPENAI_PRICES" default:"gpt-3.5-turbo:0.0015:0.002,gpt-4:0.03:0.06"`
+	// Лимиты трат на LLM в долларах за день и за месяц. При превышении используется запасной summarizer. 0 - без лимита
+	LLMDailyBudget   float64 `hcl:"llm_daily_budget" env:"LLM_DAILY_BUDGET"`
+	LLMMonthlyBudget float64 `hcl:"llm_monthly_budget" env:"LLM_MONTHLY_BUDGET"`
+	// Сколько запросов к LLM выполняется одновременно и лимиты запросов и токенов в минуту. 0 - без лимита
+	OpenAIConcurrency int `hcl:"openai_concurrency" env:"OPENAI_CONCURRENCY" default:"2"`
+	OpenAIRPM         int `hcl:"openai_rpm" env:"OPENAI_RPM" default:"60"`
+	OpenAITPM         int `hcl:"openai_tpm" env:"OPENAI_TPM" default:"60000"`
+	// После скольких ошибок подряд перестаем обращаться к LLM и на какое время. 0 выключает паузу
+	OpenAIBreakerThreshold int           `hcl:"openai_breaker_threshold" env:"OPENAI_BREAKER_THRESHOLD" default:"5"`
+	OpenAIBreakerCooldown  time.Duration `hcl:"openai_breaker_cooldown" env:"OPENAI_BREAKER_COOLDOWN" default:"5m"`
+	// Просить у LLM структурированное summary: TL;DR, 3-5 ключевых фактов и упомянутые сущности
+	SummaryStructured bool `hcl:"summary_structured" env:"SUMMARY_STRUCTURED"`
+	// Использовать экстрактивный summary, если LLM выключена, недоступна или исчерпан бюджет
+	SummaryFallback bool `hcl:"summary_fallback" env:"SUMMARY_FALLBACK" default:"true"`
+	// Сколько предложений оставляет экстрактивный summarizer
+	ExtractiveSentences int `hcl:"extractive_sentences" env:"EXTRACTIVE_SENTENCES" default:"3"`
+	// Проставлять статьям теги из словаря с помощью LLM и сколько тегов максимум выбирать
+	AutoTagging    bool `hcl:"auto_tagging" env:"AUTO_TAGGING"`
+	AutoTagMaxTags int  `hcl:"auto_tag_max_tags" env:"AUTO_TAG_MAX_TAGS" default:"3"`
+	// Оценивать релевантность статей брифам мест назначения с помощью LLM и порог по умолчанию для новых брифов
+	RelevanceScoring   bool `hcl:"relevance_scoring" env:"RELEVANCE_SCORING"`
+	RelevanceThreshold int  `hcl:"relevance_threshold" env:"RELEVANCE_THRESHOLD" default:"50"`
+	// Языки мест назначения в формате "место назначения:язык", например telegram:ru.
+	// Статьи на другом языке переводятся LLM бэкендом, пустой список выключает перевод
+	TranslationTargets []string `hcl:"translation_targets" env:"TRANSLATION_TARGETS"`
+	// Термины, которые при переводе остаются как есть, например названия продуктов
+	TranslationGlossary []string `hcl:"translation_glossary" env:"TRANSLATION_GLOSSARY"`
+	// Загрузка страниц статей без summary в ленте: таймаут запроса, максимум редиректов, максимальный размер страницы в байтах,
+	// число попыток и задержка перед повторной попыткой, которая с каждой попыткой удваивается
+	PageTimeout      time.Duration `hcl:"page_timeout" env:"PAGE_TIMEOUT" default:"15s"`
+	PageMaxRedirects int           `hcl:"page_max_redirects" env:"PAGE_MAX_REDIRECTS" default:"5"`
+	PageMaxBytes     int64         `hcl:"page_max_bytes" env:"PAGE_MAX_BYTES" default:"5242880"`
+	PageMaxAttempts  int           `hcl:"page_max_attempts" env:"PAGE_MAX_ATTEMPTS" default:"3"`
+	PageRetryBackoff time.Duration `hcl:"page_retry_backoff" env:"PAGE_RETRY_BACKOFF" default:"1s"`
+	// Текст статьи короче этого числа символов считается тизером: для summary из ленты загружается страница статьи,
+	// а если и со страницы не удалось получить больше, статья публикуется без summary с пометкой о paywall
+	ExtractionMinLength int `hcl:"extraction_min_length" env:"EXTRACTION_MIN_LENGTH" default:"500"`
+	// Сколько раз пытаемся отправить статью, прежде чем перенести ее в dead-letter. 0 - повторяем, пока не получится
+	MaxPostAttempts int `hcl:"max_post_attempts" env:"MAX_POST_ATTEMPTS" default:"5"`
+	// Базовая задержка перед повторной отправкой, с каждой попыткой удваивается
+	PostRetryBackoff time.Duration `hcl:"post_retry_backoff" env:"POST_RETRY_BACKOFF" default:"5m"`
+	// На какое время инстанс захватывает статью для отправки. Если он упадет, по истечении этого времени статью заберет другой инстанс
+	ClaimLease time.Duration `hcl:"claim_lease" env:"CLAIM_LEASE" default:"5m"`
+	// Ключ advisory lock в Postgres, по которому инстансы выбирают лидера для запуска фоновых воркеров
+	LeaderLockID int64 `hcl:"leader_lock_id" env:"LEADER_LOCK_ID" default:"7301"`
+	// Как часто инстанс пытается стать лидером, если лидер уже есть
+	LeaderRetryInterval time.Duration `hcl:"leader_retry_interval" env:"LEADER_RETRY_INTERVAL" default:"10s"`
+	// Как часто лидер проверяет, что соединение с локом не оборвалось
+	LeaderCheckInterval time.Duration `hcl:"leader_check_interval" env:"LEADER_CHECK_INTERVAL" default:"5s"`
+	// Таймаут команд админов, которые загружают страницу статьи и обращаются к LLM: /preview и /resummarize.
+	// Эти команды выполняются в фоне и не задерживают обработку остальных сообщений бота
+	LongCommandTimeout time.Duration `hcl:"long_command_timeout" env:"LONG_COMMAND_TIMEOUT" default:"2m"`
+	// Каталог с шаблонами постов: default.tmpl, source_<id>.tmpl, destination_<имя>.tmpl
+	TemplatesDir string `hcl:"templates_dir" env:"TEMPLATES_DIR" default:"./templates"`
+	// Разметка постов в телеграме: MarkdownV2 или HTML
+	TelegramParseMode string `hcl:"telegram_parse_mode" env:"TELEGRAM_PARSE_MODE" default:"MarkdownV2"`
+	// Кнопки под постами: ссылка на статью, ссылка на группу обсуждения, ссылка на ленту источника и реакции
+	PostReadButton   bool     `hcl:"post_read_button" env:"POST_READ_BUTTON" default:"false"`
+	PostDiscussURL   string   `hcl:"post_discuss_url" env:"POST_DISCUSS_URL"`
+	PostSourceButton bool     `hcl:"post_source_button" env:"POST_SOURCE_BUTTON" default:"false"`
+	PostReactions    []string `hcl:"post_reactions" env:"POST_REACTIONS"`
+	// Чат редакторов. Если задан, каждая статья сначала уходит туда и публикуется только после одобрения
+	ModerationChatID int64 `hcl:"moderation_chat_id" env:"MODERATION_CHAT_ID"`
+	// Через какое время статья, по которой никто не принял решения, снимается с модерации
+	ModerationTimeout time.Duration `hcl:"moderation_timeout" env:"MODERATION_TIMEOUT" default:"24h"`
+	// Дополнительные места назначения. Статьи публикуются в каждое, для которого задан адрес
+	DiscordWebhookURL string `hcl:"discord_webhook_url" env:"DISCORD_WEBHOOK_URL"`
+	SlackWebhookURL   string `hcl:"slack_webhook_url" env:"SLACK_WEBHOOK_URL"`
+	// Произвольный вебхук, получает статью в JSON. Если задан секрет, тело запроса подписывается HMAC-SHA256
+	WebhookURL    string `hcl:"webhook_url" env:"WEBHOOK_URL"`
+	WebhookSecret string `hcl:"webhook_secret" env:"WEBHOOK_SECRET"`
+	// Период email дайджеста: 24h для ежедневного, 168h для еженедельного. Пустое значение отключает дайджест
+	DigestPeriod  time.Duration `hcl:"digest_period" env:"DIGEST_PERIOD"`
+	DigestSubject string        `hcl:"digest_subject" env:"DIGEST_SUBJECT" default:"Дайджест новостей"`
+	// SMTP сервер, через который отправляется дайджест
+	SMTPHost     string `hcl:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `hcl:"smtp_port" env:"SMTP_PORT" default:"587"`
+	SMTPUsername string `hcl:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `hcl:"smtp_password" env:"SMTP_PASSWORD"`
+	SMTPFrom     string `hcl:"smtp_from" env:"SMTP_FROM"`
+	SMTPStartTLS bool   `hcl:"smtp_starttls" env:"SMTP_STARTTLS" default:"true"`
+	// Адрес HTTP сервера исходящей ленты RSS/Atom/JSON Feed, например :8080. Пустое значение отключает сервер
+	FeedListenAddr string `hcl:"feed_listen_addr" env:"FEED_LISTEN_ADDR"`
+	// Внешний адрес сервера ленты для ссылок. Если не задан, берется из запроса
+	FeedBaseURL  string `hcl:"feed_base_url" env:"FEED_BASE_URL"`
+	FeedTitle    string `hcl:"feed_title" env:"FEED_TITLE" default:"News Feed Bot"`
+	FeedPageSize uint64 `hcl:"feed_page_size" env:"FEED_PAGE_SIZE" default:"50"`
 }
 
 // cfg - инстанс конфига, в который мы будем читать данные
@@ -0,0 +1,184 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/page"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/render"
+	"log"
+	"time"
+)
+
+// Как часто проверяем, не пора ли отправить дайджест
+const checkInterval = 10 * time.Minute
+
+type ArticleProvider interface {
+	PostedBetween(ctx context.Context, from time.Time, to time.Time) ([]model.Article, error)
+}
+
+type SourceProvider interface {
+	SourceByID(ctx context.Context, id int64) (*model.Source, error)
+}
+
+type DigestStorage interface {
+	Recipients(ctx context.Context) ([]string, error)
+	LastSentAt(ctx context.Context) (time.Time, error)
+	MarkSent(ctx context.Context, sentAt time.Time, articlesCount int) error
+}
+
+// Возвращает, скольким получателям письмо доставлено, даже если остальным отправить не удалось
+type Mailer interface {
+	Send(ctx context.Context, recipients []string, subject string, html string, text string) (int, error)
+}
+
+// Email дайджест: раз в period собирает опубликованные за это время статьи и рассылает их одним письмом
+type Digest struct {
+	articles ArticleProvider
+	sources  SourceProvider
+	storage  DigestStorage
+	mailer   Mailer
+	renderer *render.DigestRenderer
+	// Период дайджеста, например сутки или неделя
+	period time.Duration
+	// Тема письма
+	subject string
+}
+
+func New(
+	articles ArticleProvider,
+	sources SourceProvider,
+	storage DigestStorage,
+	mailer Mailer,
+	renderer *render.DigestRenderer,
+	period time.Duration,
+	subject string,
+) *Digest {
+	return &Digest{
+		articles: articles,
+		sources:  sources,
+		storage:  storage,
+		mailer:   mailer,
+		renderer: renderer,
+		period:   period,
+		subject:  subject,
+	}
+}
+
+func (d *Digest) Start(ctx context.Context) error {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	if err := d.SendIfDue(ctx); err != nil {
+		log.Printf("[ERROR] failed to send digest: %v", err)
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := d.SendIfDue(ctx); err != nil {
+				log.Printf("[ERROR] failed to send digest: %v", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Отправляет дайджест, если с прошлой отправки прошел период.
+// Первый дайджест собирает статьи за последний период
+func (d *Digest) SendIfDue(ctx context.Context) error {
+	lastSentAt, err := d.storage.LastSentAt(ctx)
+	if err != nil {
+		return fmt.Errorf("get last digest time: %w", err)
+	}
+
+	now := time.Now()
+	if lastSentAt.IsZero() {
+		lastSentAt = now.Add(-d.period)
+	} else if now.Sub(lastSentAt) < d.period {
+		return nil
+	}
+
+	articles, err := d.articles.PostedBetween(ctx, lastSentAt, now)
+	if err != nil {
+		return fmt.Errorf("get posted articles: %w", err)
+	}
+
+	// Пустой дайджест не шлем, но период все равно закрываем, чтобы следующий не собрал лишнего
+	if len(articles) > 0 {
+		if err := d.send(ctx, lastSentAt, now, articles); err != nil {
+			return err
+		}
+	}
+
+	return d.storage.MarkSent(ctx, now, len(articles))
+}
+
+func (d *Digest) send(ctx context.Context, from time.Time, to time.Time, articles []model.Article) error {
+	recipients, err := d.storage.Recipients(ctx)
+	if err != nil {
+		return fmt.Errorf("get recipients: %w", err)
+	}
+
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	posts := make([]render.Post, 0, len(articles))
+	for _, article := range articles {
+		source, err := d.sources.SourceByID(ctx, article.SourceID)
+		if err != nil {
+			return fmt.Errorf("get source %d: %w", article.SourceID, err)
+		}
+
+		posts = append(posts, digestPost(article, *source))
+	}
+
+	html, text, err := d.renderer.Render(render.Digest{
+		Title: d.subject,
+		From:  from,
+		To:    to,
+		Posts: posts,
+	})
+	if err != nil {
+		return fmt.Errorf("render digest: %w", err)
+	}
+
+	sent, err := d.mailer.Send(ctx, recipients, d.subject, html, text)
+	if err != nil {
+		if sent == 0 {
+			return fmt.Errorf("send digest: %w", err)
+		}
+
+		// Повторная рассылка продублировала бы дайджест тем, кто его уже получил, поэтому период все равно закрываем
+		log.Printf("[ERROR] digest was sent only to %d of %d recipients: %v", sent, len(recipients), err)
+		return nil
+	}
+
+	log.Printf("[INFO] digest with %d articles sent to %d recipients", len(articles), sent)
+	return nil
+}
+
+// Пост для дайджеста. Summary в дайджесте не генерируется заново: берется то, с которым статья опубликована,
+// как в телеграме и исходящей ленте, затем исправленное редактором, и только затем описание из ленты
+func digestPost(article model.Article, source model.Source) render.Post {
+	summary := article.PublishedSummary
+	if summary == "" {
+		summary = article.EditedSummary
+	}
+	if summary == "" {
+		// Описание в ленте часто приходит в html, поэтому оставляем только текст
+		summary = page.Text(article.Summary)
+	}
+
+	return render.Post{
+		ID:          article.ID,
+		Title:       article.Title,
+		Link:        article.Link,
+		Summary:     summary,
+		SourceName:  source.Name,
+		Categories:  article.Categories,
+		PublishedAt: article.PublishedAt,
+	}
+}
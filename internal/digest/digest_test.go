@@ -0,0 +1,176 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/render"
+	"testing"
+	"time"
+)
+
+type fakeArticles struct {
+	articles []model.Article
+	from, to time.Time
+	calls    int
+}
+
+func (f *fakeArticles) PostedBetween(_ context.Context, from time.Time, to time.Time) ([]model.Article, error) {
+	f.from, f.to = from, to
+	f.calls++
+	return f.articles, nil
+}
+
+type fakeSources struct{}
+
+func (fakeSources) SourceByID(_ context.Context, id int64) (*model.Source, error) {
+	return &model.Source{ID: id, Name: "source"}, nil
+}
+
+type fakeStorage struct {
+	recipients []string
+	lastSentAt time.Time
+	marked     bool
+	markedAt   time.Time
+	count      int
+}
+
+func (f *fakeStorage) Recipients(context.Context) ([]string, error) {
+	return f.recipients, nil
+}
+
+func (f *fakeStorage) LastSentAt(context.Context) (time.Time, error) {
+	return f.lastSentAt, nil
+}
+
+func (f *fakeStorage) MarkSent(_ context.Context, sentAt time.Time, articlesCount int) error {
+	f.marked, f.markedAt, f.count = true, sentAt, articlesCount
+	return nil
+}
+
+type fakeMailer struct {
+	sent  int
+	err   error
+	calls int
+}
+
+func (f *fakeMailer) Send(_ context.Context, recipients []string, _ string, _ string, _ string) (int, error) {
+	f.calls++
+	if f.err != nil {
+		return f.sent, f.err
+	}
+	return len(recipients), nil
+}
+
+func newTestDigest(t *testing.T, articles *fakeArticles, storage *fakeStorage, mailer *fakeMailer) *Digest {
+	t.Helper()
+
+	renderer, err := render.LoadDigest(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return New(articles, fakeSources{}, storage, mailer, renderer, 24*time.Hour, "Дайджест")
+}
+
+func posted() []model.Article {
+	return []model.Article{{ID: 1, SourceID: 1, Title: "Новость", Link: "https://example.com/1"}}
+}
+
+func TestSendIfDueFirstDigestCoversLastPeriod(t *testing.T) {
+	articles := &fakeArticles{articles: posted()}
+	storage := &fakeStorage{recipients: []string{"alice@example.com"}}
+	mailer := &fakeMailer{}
+
+	if err := newTestDigest(t, articles, storage, mailer).SendIfDue(context.Background()); err != nil {
+		t.Fatalf("SendIfDue() error = %v", err)
+	}
+
+	if got := articles.to.Sub(articles.from); got != 24*time.Hour {
+		t.Errorf("first digest covers %s, want 24h", got)
+	}
+	if mailer.calls != 1 {
+		t.Errorf("mailer called %d times, want 1", mailer.calls)
+	}
+	if !storage.marked || storage.count != 1 || !storage.markedAt.Equal(articles.to) {
+		t.Errorf("MarkSent() = %v at %s with %d articles, want at %s with 1 article", storage.marked, storage.markedAt, storage.count, articles.to)
+	}
+}
+
+func TestSendIfDueWaitsForPeriod(t *testing.T) {
+	articles := &fakeArticles{articles: posted()}
+	storage := &fakeStorage{recipients: []string{"alice@example.com"}, lastSentAt: time.Now().Add(-time.Hour)}
+	mailer := &fakeMailer{}
+
+	if err := newTestDigest(t, articles, storage, mailer).SendIfDue(context.Background()); err != nil {
+		t.Fatalf("SendIfDue() error = %v", err)
+	}
+
+	if articles.calls != 0 || mailer.calls != 0 || storage.marked {
+		t.Errorf("digest was sent before the period passed")
+	}
+}
+
+func TestSendIfDueCoversTimeSinceLastDigest(t *testing.T) {
+	lastSentAt := time.Now().Add(-30 * time.Hour)
+	articles := &fakeArticles{articles: posted()}
+	storage := &fakeStorage{recipients: []string{"alice@example.com"}, lastSentAt: lastSentAt}
+	mailer := &fakeMailer{}
+
+	if err := newTestDigest(t, articles, storage, mailer).SendIfDue(context.Background()); err != nil {
+		t.Fatalf("SendIfDue() error = %v", err)
+	}
+
+	if !articles.from.Equal(lastSentAt) {
+		t.Errorf("digest starts at %s, want last digest time %s", articles.from, lastSentAt)
+	}
+	if mailer.calls != 1 || !storage.marked {
+		t.Errorf("digest was not sent after the period passed")
+	}
+}
+
+func TestSendIfDueMarksEmptyPeriod(t *testing.T) {
+	articles := &fakeArticles{}
+	storage := &fakeStorage{recipients: []string{"alice@example.com"}}
+	mailer := &fakeMailer{}
+
+	if err := newTestDigest(t, articles, storage, mailer).SendIfDue(context.Background()); err != nil {
+		t.Fatalf("SendIfDue() error = %v", err)
+	}
+
+	if mailer.calls != 0 {
+		t.Errorf("empty digest was sent")
+	}
+	if !storage.marked || storage.count != 0 {
+		t.Errorf("empty period was not closed")
+	}
+}
+
+func TestSendIfDueMarksPartiallySentDigest(t *testing.T) {
+	articles := &fakeArticles{articles: posted()}
+	storage := &fakeStorage{recipients: []string{"alice@example.com", "missing@example.com"}}
+	mailer := &fakeMailer{sent: 1, err: errors.New("missing@example.com: 550 no such user")}
+
+	if err := newTestDigest(t, articles, storage, mailer).SendIfDue(context.Background()); err != nil {
+		t.Fatalf("SendIfDue() error = %v", err)
+	}
+
+	// Иначе при следующей проверке дайджест получат повторно все, кто его уже получил
+	if !storage.marked {
+		t.Errorf("partially sent digest was not marked as sent")
+	}
+}
+
+func TestSendIfDueRetriesFailedDigest(t *testing.T) {
+	articles := &fakeArticles{articles: posted()}
+	storage := &fakeStorage{recipients: []string{"alice@example.com"}}
+	mailer := &fakeMailer{err: errors.New("connection refused")}
+
+	if err := newTestDigest(t, articles, storage, mailer).SendIfDue(context.Background()); err == nil {
+		t.Fatal("SendIfDue() error = nil, want error")
+	}
+
+	if storage.marked {
+		t.Errorf("digest that nobody received was marked as sent")
+	}
+}
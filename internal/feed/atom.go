@@ -0,0 +1,100 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+const atomNS = "http://www.w3.org/2005/Atom"
+
+type atomDocument struct {
+	XMLName  xml.Name    `xml:"feed"`
+	NS       string      `xml:"xmlns,attr"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    string         `xml:"summary,omitempty"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// Кодирует ленту в Atom
+func encodeAtom(feed Feed) ([]byte, error) {
+	doc := atomDocument{
+		NS:       atomNS,
+		ID:       feed.ID,
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		Updated:  feed.Updated.UTC().Format(time.RFC3339),
+		Links:    pageLinks(feed),
+	}
+
+	for _, item := range feed.Items {
+		entry := atomEntry{
+			ID:        item.GUID(),
+			Title:     item.Title,
+			Link:      atomLink{Rel: "alternate", Href: item.Link},
+			Published: item.PostedAt.UTC().Format(time.RFC3339),
+			Updated:   item.PostedAt.UTC().Format(time.RFC3339),
+			Summary:   item.Summary,
+		}
+
+		if item.SourceName != "" {
+			entry.Author = &atomPerson{Name: item.SourceName, URI: item.SourceURL}
+		}
+
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), body...), nil
+}
+
+// Ссылки на саму страницу ленты и на соседние страницы
+func pageLinks(feed Feed) []atomLink {
+	links := []atomLink{{Rel: "self", Href: feed.SelfURL}}
+
+	if feed.NextURL != "" {
+		links = append(links, atomLink{Rel: "next", Href: feed.NextURL})
+	}
+
+	if feed.PrevURL != "" {
+		links = append(links, atomLink{Rel: "previous", Href: feed.PrevURL})
+	}
+
+	return links
+}
@@ -0,0 +1,56 @@
+package feed
+
+import (
+	"fmt"
+	"time"
+)
+
+// Формат исходящей ленты, совпадает с расширением в адресе: feed.rss, feed.atom, feed.json
+type Format string
+
+const (
+	FormatRSS  Format = "rss"
+	FormatAtom Format = "atom"
+	FormatJSON Format = "json"
+)
+
+// Content-Type ответа для каждого формата
+var contentTypes = map[Format]string{
+	FormatRSS:  "application/rss+xml; charset=utf-8",
+	FormatAtom: "application/atom+xml; charset=utf-8",
+	FormatJSON: "application/feed+json; charset=utf-8",
+}
+
+// Лента, которая кодируется в один из форматов
+type Feed struct {
+	// Постоянный идентификатор ленты, адрес ее первой страницы
+	ID          string
+	Title       string
+	Description string
+	// Адрес этой страницы ленты и соседних страниц. Пустой адрес означает, что страницы нет
+	SelfURL string
+	NextURL string
+	PrevURL string
+	// Время последнего обновления ленты, берется по самой свежей статье
+	Updated time.Time
+	Items   []Item
+}
+
+// Опубликованная статья в ленте
+type Item struct {
+	ID         int64
+	Title      string
+	Link       string
+	Summary    string
+	SourceName string
+	SourceURL  string
+	Categories []string
+	// Когда статья вышла в источнике и когда попала в нашу ленту
+	PublishedAt time.Time
+	PostedAt    time.Time
+}
+
+// Постоянный идентификатор статьи в ленте. Ссылка на статью для этого не подходит: одна статья может прийти из нескольких источников
+func (i Item) GUID() string {
+	return fmt.Sprintf("urn:news-feed-bot:article:%d", i.ID)
+}
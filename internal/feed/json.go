@@ -0,0 +1,63 @@
+package feed
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	FeedURL     string         `json:"feed_url"`
+	NextURL     string         `json:"next_url,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentText   string           `json:"content_text"`
+	DatePublished string           `json:"date_published"`
+	Tags          []string         `json:"tags,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// Кодирует ленту в JSON Feed 1.1. Предыдущей страницы в формате нет, только next_url
+func encodeJSON(feed Feed) ([]byte, error) {
+	doc := jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       feed.Title,
+		Description: feed.Description,
+		FeedURL:     feed.SelfURL,
+		NextURL:     feed.NextURL,
+		Items:       make([]jsonFeedItem, 0, len(feed.Items)),
+	}
+
+	for _, item := range feed.Items {
+		jsonItem := jsonFeedItem{
+			ID:            item.GUID(),
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentText:   item.Summary,
+			DatePublished: item.PostedAt.UTC().Format(time.RFC3339),
+			Tags:          item.Categories,
+		}
+
+		if item.SourceName != "" {
+			jsonItem.Authors = []jsonFeedAuthor{{Name: item.SourceName, URL: item.SourceURL}}
+		}
+
+		doc.Items = append(doc.Items, jsonItem)
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,85 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+	Links         []atomLink `xml:"atom:link"`
+	Items         []rssItem  `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link"`
+	Description string         `xml:"description,omitempty"`
+	GUID        rssGUID        `xml:"guid"`
+	PubDate     string         `xml:"pubDate"`
+	Categories  []string       `xml:"category"`
+	Source      *rssItemSource `xml:"source,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItemSource struct {
+	URL  string `xml:"url,attr"`
+	Name string `xml:",chardata"`
+}
+
+// Кодирует ленту в RSS 2.0. Ссылки на соседние страницы передаются через atom:link, как в RFC 5005
+func encodeRSS(feed Feed) ([]byte, error) {
+	channel := rssChannel{
+		Title:       feed.Title,
+		Link:        feed.SelfURL,
+		Description: feed.Description,
+		Links:       pageLinks(feed),
+	}
+
+	if !feed.Updated.IsZero() {
+		channel.LastBuildDate = feed.Updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for _, item := range feed.Items {
+		rss := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Summary,
+			GUID:        rssGUID{Value: item.GUID()},
+			PubDate:     item.PostedAt.UTC().Format(time.RFC1123Z),
+			Categories:  item.Categories,
+		}
+
+		// В RSS у элемента source адрес ленты обязателен
+		if item.SourceURL != "" {
+			rss.Source = &rssItemSource{URL: item.SourceURL, Name: item.SourceName}
+		}
+
+		channel.Items = append(channel.Items, rss)
+	}
+
+	body, err := xml.MarshalIndent(rssDocument{
+		Version: "2.0",
+		AtomNS:  atomNS,
+		Channel: channel,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,338 @@
+package feed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Сколько ждем завершения активных запросов при остановке сервера
+const shutdownTimeout = 5 * time.Second
+
+// Размер страницы ленты, если в конфиге указан 0
+const defaultPageSize = 50
+
+type ArticleProvider interface {
+	Posted(ctx context.Context, destination string, category string, limit uint64, offset uint64) ([]model.Article, error)
+}
+
+type SourceProvider interface {
+	Sources(ctx context.Context) ([]model.Source, error)
+}
+
+// HTTP сервер исходящей ленты. Отдает опубликованные статьи с их summary в RSS 2.0, Atom и JSON Feed:
+//
+//	/feed.{rss,atom,json} - все опубликованные статьи
+//	/destinations/<имя>/feed.{rss,atom,json} - статьи, доставленные в место назначения
+//	/categories/<категория>/feed.{rss,atom,json} - статьи с категорией из ленты или тегом из словаря
+//
+// Страница выбирается параметром page, начиная с 1
+type Server struct {
+	articles ArticleProvider
+	sources  SourceProvider
+	// Адрес, на котором слушает сервер
+	addr string
+	// Внешний адрес сервера для ссылок в лентах. Если пустой, берется из запроса
+	baseURL string
+	// Название ленты
+	title string
+	// Сколько статей на одной странице ленты
+	pageSize uint64
+}
+
+func NewServer(
+	articles ArticleProvider,
+	sources SourceProvider,
+	addr string,
+	baseURL string,
+	title string,
+	pageSize uint64,
+) *Server {
+	// С нулевым размером каждая страница была бы пустой, а ссылка на следующую - бесконечной
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+
+	return &Server{
+		articles: articles,
+		sources:  sources,
+		addr:     addr,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		title:    title,
+		pageSize: pageSize,
+	}
+}
+
+// Запускает сервер и останавливает его при отмене контекста
+func (s *Server) Start(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return ctx.Err()
+	}
+}
+
+// Запрос ленты, разобранный из адреса
+type request struct {
+	destination string
+	category    string
+	format      Format
+	page        uint64
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, ok := parseRequest(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	feed, err := s.feed(r.Context(), req, s.base(r))
+	if err != nil {
+		log.Printf("[ERROR] failed to build feed %s: %v", r.URL.Path, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	body, err := encode(feed, req.format)
+	if err != nil {
+		log.Printf("[ERROR] failed to encode feed %s: %v", r.URL.Path, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	// ETag считаем по телу ответа: лента меняется только когда публикуется или редактируется статья
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if matchETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentTypes[req.format])
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if _, err := w.Write(body); err != nil {
+		log.Printf("[ERROR] failed to write feed %s: %v", r.URL.Path, err)
+	}
+}
+
+// Собирает страницу ленты
+func (s *Server) feed(ctx context.Context, req request, base string) (Feed, error) {
+	// Запрашиваем на одну статью больше, чтобы понять, есть ли следующая страница
+	articles, err := s.articles.Posted(ctx, req.destination, req.category, s.pageSize+1, (req.page-1)*s.pageSize)
+	if err != nil {
+		return Feed{}, err
+	}
+
+	sources, err := s.sources.Sources(ctx)
+	if err != nil {
+		return Feed{}, err
+	}
+
+	sourcesByID := make(map[int64]model.Source, len(sources))
+	for _, source := range sources {
+		sourcesByID[source.ID] = source
+	}
+
+	hasNext := uint64(len(articles)) > s.pageSize
+	if hasNext {
+		articles = articles[:s.pageSize]
+	}
+
+	feed := Feed{
+		ID:          pageURL(base, req, 1),
+		Title:       s.title,
+		Description: s.title,
+		SelfURL:     pageURL(base, req, req.page),
+	}
+
+	switch {
+	case req.destination != "":
+		feed.Title = fmt.Sprintf("%s: %s", s.title, req.destination)
+	case req.category != "":
+		feed.Title = fmt.Sprintf("%s: %s", s.title, req.category)
+	}
+
+	if hasNext {
+		feed.NextURL = pageURL(base, req, req.page+1)
+	}
+
+	if req.page > 1 {
+		feed.PrevURL = pageURL(base, req, req.page-1)
+	}
+
+	for _, article := range articles {
+		source := sourcesByID[article.SourceID]
+
+		feed.Items = append(feed.Items, Item{
+			ID:          article.ID,
+			Title:       article.Title,
+			Link:        article.Link,
+			Summary:     article.PublishedSummary,
+			SourceName:  source.Name,
+			SourceURL:   source.FeedURL,
+			Categories:  categories(article),
+			PublishedAt: article.PublishedAt,
+			PostedAt:    article.PostedAt,
+		})
+
+		if article.PostedAt.After(feed.Updated) {
+			feed.Updated = article.PostedAt
+		}
+	}
+
+	return feed, nil
+}
+
+// Внешний адрес сервера
+func (s *Server) base(r *http.Request) string {
+	if s.baseURL != "" {
+		return s.baseURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+// Разбирает адрес вида [/destinations/<имя>|/categories/<категория>]/feed.<формат>?page=N
+func parseRequest(r *http.Request) (request, bool) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	var req request
+	switch {
+	case len(parts) == 1:
+	case len(parts) == 3 && parts[0] == "destinations" && parts[1] != "":
+		req.destination = parts[1]
+	case len(parts) == 3 && parts[0] == "categories" && parts[1] != "":
+		req.category = parts[1]
+	default:
+		return request{}, false
+	}
+
+	name := parts[len(parts)-1]
+	if !strings.HasPrefix(name, "feed.") {
+		return request{}, false
+	}
+
+	req.format = Format(strings.TrimPrefix(name, "feed."))
+	if _, ok := contentTypes[req.format]; !ok {
+		return request{}, false
+	}
+
+	req.page = 1
+	if page := r.URL.Query().Get("page"); page != "" {
+		n, err := strconv.ParseUint(page, 10, 64)
+		if err != nil || n == 0 {
+			return request{}, false
+		}
+		req.page = n
+	}
+
+	return req, true
+}
+
+// Адрес страницы page той же ленты
+func pageURL(base string, req request, page uint64) string {
+	path := "/feed." + string(req.format)
+
+	switch {
+	case req.destination != "":
+		path = "/destinations/" + url.PathEscape(req.destination) + path
+	case req.category != "":
+		path = "/categories/" + url.PathEscape(req.category) + path
+	}
+
+	if page > 1 {
+		path += "?page=" + strconv.FormatUint(page, 10)
+	}
+
+	return base + path
+}
+
+func encode(feed Feed, format Format) ([]byte, error) {
+	switch format {
+	case FormatRSS:
+		return encodeRSS(feed)
+	case FormatAtom:
+		return encodeAtom(feed)
+	default:
+		return encodeJSON(feed)
+	}
+}
+
+// Проверяет заголовок If-None-Match, в котором может быть несколько ETag через запятую или *
+func matchETag(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Категории статьи в ленте: категории из ленты источника и теги из словаря, без повторов.
+// По ним же работает фильтр /categories/<категория>
+func categories(article model.Article) []string {
+	result := make([]string, 0, len(article.Categories)+len(article.Tags))
+	seen := make(map[string]bool, cap(result))
+
+	for _, category := range append(append([]string(nil), article.Categories...), article.Tags...) {
+		key := strings.ToLower(category)
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		result = append(result, category)
+	}
+
+	return result
+}
@@ -0,0 +1,286 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Хранилище опубликованных статей в памяти. Фильтрует как ArticlePostgresStorage.Posted и запоминает запросы
+type fakeArticles struct {
+	articles []model.Article
+	calls    []postedCall
+}
+
+type postedCall struct {
+	destination string
+	category    string
+	limit       uint64
+	offset      uint64
+}
+
+func (f *fakeArticles) Posted(_ context.Context, destination string, category string, limit uint64, offset uint64) ([]model.Article, error) {
+	f.calls = append(f.calls, postedCall{destination: destination, category: category, limit: limit, offset: offset})
+
+	var matched []model.Article
+	for _, article := range f.articles {
+		if destination != "" && destination != "telegram" {
+			continue
+		}
+		if category != "" && !hasCategory(article, category) {
+			continue
+		}
+		matched = append(matched, article)
+	}
+
+	if offset >= uint64(len(matched)) {
+		return nil, nil
+	}
+	matched = matched[offset:]
+	if uint64(len(matched)) > limit {
+		matched = matched[:limit]
+	}
+
+	return matched, nil
+}
+
+func hasCategory(article model.Article, category string) bool {
+	for _, c := range append(append([]string(nil), article.Categories...), article.Tags...) {
+		if strings.EqualFold(c, category) {
+			return true
+		}
+	}
+	return false
+}
+
+type fakeSources struct{}
+
+func (fakeSources) Sources(context.Context) ([]model.Source, error) {
+	return []model.Source{{ID: 1, Name: "Go Blog", FeedURL: "https://go.dev/blog/feed.atom"}}, nil
+}
+
+// Статьи от новых к старым, с категориями из ленты у четных и тегами из словаря у нечетных
+func testArticles(n int) []model.Article {
+	postedAt := time.Date(2023, 8, 8, 12, 0, 0, 0, time.UTC)
+
+	articles := make([]model.Article, 0, n)
+	for i := n; i > 0; i-- {
+		article := model.Article{
+			ID:               int64(i),
+			SourceID:         1,
+			Title:            fmt.Sprintf("Статья %d", i),
+			Link:             fmt.Sprintf("https://example.com/%d", i),
+			PublishedSummary: "Краткое содержание",
+			PostedAt:         postedAt.Add(time.Duration(i) * time.Hour),
+		}
+		if i%2 == 0 {
+			article.Categories = []string{"Go"}
+		} else {
+			article.Categories = []string{"news"}
+			article.Tags = []string{"Go", "News"}
+		}
+		articles = append(articles, article)
+	}
+
+	return articles
+}
+
+func newTestServer(articles *fakeArticles, pageSize uint64) *httptest.Server {
+	return httptest.NewServer(NewServer(articles, fakeSources{}, "", "https://feed.example.com/", "Новости", pageSize))
+}
+
+func getJSONFeed(t *testing.T, url string) jsonFeed {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s status = %d, want 200", url, resp.StatusCode)
+	}
+
+	var feed jsonFeed
+	if err := json.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		t.Fatalf("decode feed: %v", err)
+	}
+
+	return feed
+}
+
+func TestServerETag(t *testing.T) {
+	server := newTestServer(&fakeArticles{articles: testArticles(3)}, 10)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/feed.rss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		t.Fatalf("GET status = %d with ETag %q, want 200 with ETag", resp.StatusCode, etag)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != contentTypes[FormatRSS] {
+		t.Errorf("Content-Type = %q, want %q", ct, contentTypes[FormatRSS])
+	}
+
+	tests := []struct {
+		ifNoneMatch string
+		want        int
+	}{
+		{ifNoneMatch: etag, want: http.StatusNotModified},
+		{ifNoneMatch: "W/" + etag, want: http.StatusNotModified},
+		{ifNoneMatch: `"other", ` + etag, want: http.StatusNotModified},
+		{ifNoneMatch: "*", want: http.StatusNotModified},
+		{ifNoneMatch: `"other"`, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/feed.rss", nil)
+		req.Header.Set("If-None-Match", tt.ifNoneMatch)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.want {
+			t.Errorf("If-None-Match %s: status = %d, want %d", tt.ifNoneMatch, resp.StatusCode, tt.want)
+		}
+		if resp.Header.Get("ETag") != etag {
+			t.Errorf("If-None-Match %s: ETag = %q, want %q", tt.ifNoneMatch, resp.Header.Get("ETag"), etag)
+		}
+	}
+}
+
+func TestServerPagination(t *testing.T) {
+	articles := &fakeArticles{articles: testArticles(5)}
+	server := newTestServer(articles, 2)
+	defer server.Close()
+
+	first := getJSONFeed(t, server.URL+"/feed.json")
+	if len(first.Items) != 2 || first.Items[0].Title != "Статья 5" {
+		t.Fatalf("first page has %d items starting with %+v, want 2 newest", len(first.Items), first.Items)
+	}
+	if first.FeedURL != "https://feed.example.com/feed.json" {
+		t.Errorf("feed_url = %q", first.FeedURL)
+	}
+	if first.NextURL != "https://feed.example.com/feed.json?page=2" {
+		t.Errorf("next_url = %q, want page 2", first.NextURL)
+	}
+
+	last := getJSONFeed(t, server.URL+"/feed.json?page=3")
+	if len(last.Items) != 1 || last.Items[0].Title != "Статья 1" {
+		t.Errorf("last page has %+v, want only the oldest article", last.Items)
+	}
+	if last.NextURL != "" {
+		t.Errorf("last page next_url = %q, want none", last.NextURL)
+	}
+
+	// Запрашиваем на одну статью больше страницы, чтобы узнать о следующей
+	if call := articles.calls[len(articles.calls)-1]; call.limit != 3 || call.offset != 4 {
+		t.Errorf("Posted() called with limit %d offset %d, want limit 3 offset 4", call.limit, call.offset)
+	}
+
+	resp, err := http.Get(server.URL + "/feed.atom?page=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, link := range []string{
+		`rel="next" href="https://feed.example.com/feed.atom?page=3"`,
+		`rel="previous" href="https://feed.example.com/feed.atom"`,
+	} {
+		if !strings.Contains(string(body), link) {
+			t.Errorf("atom page 2 has no link %s:\n%s", link, body)
+		}
+	}
+}
+
+func TestServerFilters(t *testing.T) {
+	articles := &fakeArticles{articles: testArticles(4)}
+	server := newTestServer(articles, 10)
+	defer server.Close()
+
+	feed := getJSONFeed(t, server.URL+"/categories/go/feed.json")
+	if len(feed.Items) != 4 {
+		t.Errorf("category feed has %d items, want 4 with category or tag go", len(feed.Items))
+	}
+	if feed.Title != "Новости: go" {
+		t.Errorf("category feed title = %q", feed.Title)
+	}
+	if call := articles.calls[len(articles.calls)-1]; call.category != "go" || call.destination != "" {
+		t.Errorf("Posted() called with %+v, want category go", call)
+	}
+
+	// Категории из ленты и теги из словаря объединяются без повторов
+	if tags := strings.Join(feed.Items[1].Tags, ","); tags != "news,Go" {
+		t.Errorf("item tags = %q, want news,Go", tags)
+	}
+
+	feed = getJSONFeed(t, server.URL+"/destinations/slack/feed.json")
+	if len(feed.Items) != 0 {
+		t.Errorf("destination feed has %d items, want none", len(feed.Items))
+	}
+	if call := articles.calls[len(articles.calls)-1]; call.destination != "slack" || call.category != "" {
+		t.Errorf("Posted() called with %+v, want destination slack", call)
+	}
+	if feed.FeedURL != "https://feed.example.com/destinations/slack/feed.json" {
+		t.Errorf("feed_url = %q", feed.FeedURL)
+	}
+}
+
+func TestServerNotFound(t *testing.T) {
+	server := newTestServer(&fakeArticles{}, 10)
+	defer server.Close()
+
+	for _, path := range []string{
+		"/feed.xml",
+		"/rss",
+		"/categories//feed.json",
+		"/destinations/telegram/extra/feed.json",
+		"/feed.json?page=0",
+		"/feed.json?page=abc",
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s status = %d, want 404", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestServerDefaultPageSize(t *testing.T) {
+	articles := &fakeArticles{articles: testArticles(3)}
+	server := newTestServer(articles, 0)
+	defer server.Close()
+
+	feed := getJSONFeed(t, server.URL+"/feed.json")
+	if len(feed.Items) != 3 || feed.NextURL != "" {
+		t.Errorf("feed has %d items and next_url %q, want all 3 on one page", len(feed.Items), feed.NextURL)
+	}
+	if call := articles.calls[0]; call.limit != defaultPageSize+1 {
+		t.Errorf("Posted() called with limit %d, want %d", call.limit, defaultPageSize+1)
+	}
+}
@@ -2,6 +2,7 @@ package fetcher
 
 import (
 	"context"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/lang"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/source"
 	"log"
@@ -39,18 +40,27 @@ type Fetcher struct {
 	fetchInterval time.Duration
 	// Фильтрация статей по ключевым словами
 	filterKeyWords []string
+	// Языки, статьи на которых сохраняются. Пустой список - любые языки
+	filterLanguages []string
 }
 
 // Что то типо конструктора.
 // Указываем все те параметры, который указаны как поля структуры.
 // Делаем это для того, чтобы их неьзя было менять извне.
 // Скрываем их, делаем не экспортируемыми и передаем в конструктор
-func NewFetcher(articleStorage ArticleStorage, sourceProvider SourceProvider, fetchInterval time.Duration, filterKeyWords []string) *Fetcher {
+func NewFetcher(
+	articleStorage ArticleStorage,
+	sourceProvider SourceProvider,
+	fetchInterval time.Duration,
+	filterKeyWords []string,
+	filterLanguages []string,
+) *Fetcher {
 	return &Fetcher{
-		articles:       articleStorage,
-		sources:        sourceProvider,
-		fetchInterval:  fetchInterval,
-		filterKeyWords: filterKeyWords,
+		articles:        articleStorage,
+		sources:         sourceProvider,
+		fetchInterval:   fetchInterval,
+		filterKeyWords:  filterKeyWords,
+		filterLanguages: filterLanguages,
 	}
 }
 
@@ -127,12 +137,27 @@ func (f *Fetcher) processItems(ctx context.Context, source Source, items []model
 			continue
 		}
 
+		// Язык определяем по заголовку и summary из ленты, при отправке он уточняется по полному тексту
+		language := lang.Detect(item.Title + "\n" + item.Summary)
+		if f.languageShouldBeSkipped(language) {
+			continue
+		}
+
+		// Не во всех лентах есть GUID, тогда статью узнаем по ссылке
+		guid := item.GUID
+		if guid == "" {
+			guid = item.Link
+		}
+
 		// Если все ок, сохраняем статью в ArcticleStorage
 		if err := f.articles.Store(ctx, model.Article{
 			SourceID:    source.ID(),
+			GUID:        guid,
 			Title:       item.Title,
 			Link:        item.Link,
 			Summary:     item.Summary,
+			Categories:  item.Categories,
+			Language:    language,
 			PublishedAt: item.Date,
 		}); err != nil {
 			return err
@@ -164,3 +189,18 @@ func (f *Fetcher) itemShouldBeSkipped(item model.Item) bool {
 
 	return false
 }
+
+// Пропускаем статьи на языках не из списка. Статьи, язык которых определить не удалось, не пропускаем
+func (f *Fetcher) languageShouldBeSkipped(language string) bool {
+	if len(f.filterLanguages) == 0 || language == "" {
+		return false
+	}
+
+	for _, allowed := range f.filterLanguages {
+		if strings.EqualFold(allowed, language) {
+			return false
+		}
+	}
+
+	return true
+}
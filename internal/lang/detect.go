@@ -0,0 +1,189 @@
+package lang
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Сколько букв нужно, чтобы уверенно определить язык
+const minLetters = 20
+
+// Языковой профиль: алфавит, самые частые служебные слова и буквы, которые есть только в этом языке
+type profile struct {
+	code      string
+	script    *unicode.RangeTable
+	stopwords map[string]struct{}
+	letters   string
+}
+
+// Профили одного алфавита перечислены по убыванию приоритета: при равенстве выбирается первый
+var profiles = []profile{
+	{
+		code:   "ru",
+		script: unicode.Cyrillic,
+		stopwords: words("и в не на что с по как это из за о от для к до же но так уже его она они был была было " +
+			"были который которые также только если или при чтобы еще этот можно будет"),
+		letters: "ыэъё",
+	},
+	{
+		code:   "uk",
+		script: unicode.Cyrillic,
+		stopwords: words("і й та що не на в у з до як це за від для але про його її вони був була було були який " +
+			"які також тільки якщо або при щоб ще цей можна буде"),
+		letters: "іїєґ",
+	},
+	{
+		code:   "en",
+		script: unicode.Latin,
+		stopwords: words("the and of to in is that for it with as was on are be by this from at an have has " +
+			"which not but or will their they been were would can its new after about"),
+	},
+	{
+		code:   "de",
+		script: unicode.Latin,
+		stopwords: words("der die das und ist nicht mit den von zu sich des auf für im dem ein eine einen als " +
+			"auch es an werden aus er hat dass sie nach wird bei noch wie über"),
+		letters: "äöüß",
+	},
+	{
+		code:   "fr",
+		script: unicode.Latin,
+		stopwords: words("le la les et est des un une du que qui dans pour pas sur au avec il elle sont ce " +
+			"cette mais ou par plus son ses leur nous vous été aux"),
+		letters: "èêçœ",
+	},
+	{
+		code:   "es",
+		script: unicode.Latin,
+		stopwords: words("el la los las y es en que del un una por con para se su sus al lo como pero más " +
+			"este esta fue son ha han está también entre"),
+		letters: "ñ¿¡",
+	},
+	{
+		code:   "it",
+		script: unicode.Latin,
+		stopwords: words("il la lo gli le e è di che un una per con non si del della dei sono nel nella alla " +
+			"anche come ma più questo questa stato essere"),
+	},
+	{
+		code:   "pt",
+		script: unicode.Latin,
+		stopwords: words("o a os as e é de do da dos das que um uma para com não se no na por mais mas foi " +
+			"são como ao ele ela também está"),
+		letters: "ãõ",
+	},
+}
+
+// Определяет язык текста без обращения к внешним сервисам.
+// Сначала по преобладающему алфавиту, затем среди языков этого алфавита по частым служебным словам и особым буквам.
+// HTML теги не учитываются, поэтому можно передавать summary из ленты как есть.
+// Если букв слишком мало или ни один алфавит не преобладает, возвращает пустую строку
+func Detect(text string) string {
+	words := tokenize(text)
+
+	scripts := make(map[*unicode.RangeTable]int)
+	var letters int
+
+	for _, word := range words {
+		for _, r := range word {
+			letters++
+
+			for _, p := range profiles {
+				if unicode.Is(p.script, r) {
+					scripts[p.script]++
+					break
+				}
+			}
+		}
+	}
+
+	if letters < minLetters {
+		return ""
+	}
+
+	// Язык определяем, только если алфавит занимает явное большинство: в русских текстах много латинских терминов
+	var script *unicode.RangeTable
+	for table, count := range scripts {
+		if count*3 >= letters*2 {
+			script = table
+		}
+	}
+
+	if script == nil {
+		return ""
+	}
+
+	best, bestScore := "", -1
+	for _, p := range profiles {
+		if p.script != script {
+			continue
+		}
+
+		if score := p.score(words); score > bestScore {
+			best, bestScore = p.code, score
+		}
+	}
+
+	// Если ни одного признака не нашлось, остается первый язык алфавита
+	return best
+}
+
+// Служебное слово весит больше особой буквы: буквы встречаются и в заимствованных именах
+func (p profile) score(words []string) int {
+	var score int
+
+	for _, word := range words {
+		if _, ok := p.stopwords[word]; ok {
+			score += 2
+		}
+
+		if p.letters != "" && strings.ContainsAny(word, p.letters) {
+			score++
+		}
+	}
+
+	return score
+}
+
+// Разбивает текст на слова в нижнем регистре, пропуская HTML теги
+func tokenize(text string) []string {
+	var (
+		words []string
+		word  strings.Builder
+		inTag bool
+	)
+
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case r == '<':
+			flush()
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+		case inTag:
+		case unicode.IsLetter(r):
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return words
+}
+
+func words(list string) map[string]struct{} {
+	result := make(map[string]struct{})
+	for _, word := range strings.Fields(list) {
+		result[word] = struct{}{}
+	}
+
+	return result
+}
@@ -0,0 +1,27 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Разбирает языки мест назначения в формате "место назначения:язык", например "telegram:ru"
+func ParseTargets(targets []string) (map[string]string, error) {
+	result := make(map[string]string, len(targets))
+
+	for _, target := range targets {
+		if strings.TrimSpace(target) == "" {
+			continue
+		}
+
+		destination, language, ok := strings.Cut(target, ":")
+		destination, language = strings.TrimSpace(destination), strings.ToLower(strings.TrimSpace(language))
+		if !ok || destination == "" || language == "" {
+			return nil, fmt.Errorf("invalid target language %q, expected destination:language", target)
+		}
+
+		result[destination] = language
+	}
+
+	return result, nil
+}
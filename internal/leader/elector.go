@@ -0,0 +1,127 @@
+package leader
+
+import (
+	"context"
+	"database/sql/driver"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Выбор лидера среди нескольких инстансов бота через advisory lock в Postgres.
+// Лок держится на выделенном соединении: пока соединение живо, инстанс остается лидером.
+// Если соединение оборвалось, Postgres сам снимает лок, и его сможет захватить другой инстанс
+type Elector struct {
+	db *sqlx.DB
+	// Ключ advisory lock, должен совпадать у всех инстансов
+	lockID int64
+	// Как часто пытаемся стать лидером, если лок занят
+	retryInterval time.Duration
+	// Как часто лидер проверяет, что соединение с локом еще живо
+	checkInterval time.Duration
+}
+
+func NewElector(db *sqlx.DB, lockID int64, retryInterval time.Duration, checkInterval time.Duration) *Elector {
+	return &Elector{
+		db:            db,
+		lockID:        lockID,
+		retryInterval: retryInterval,
+		checkInterval: checkInterval,
+	}
+}
+
+// Метод блокируется до отмены ctx.
+// Каждый раз, когда инстанс становится лидером, вызывается fn с контекстом, который отменяется при потере лидерства.
+// После потери лидерства Elector снова пытается захватить лок
+func (e *Elector) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	ticker := time.NewTicker(e.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.lead(ctx, fn); err != nil && ctx.Err() == nil {
+			log.Printf("[ERROR] leader election: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Одна попытка стать лидером. Если лок получен, fn выполняется, пока мы лидер
+func (e *Elector) lead(ctx context.Context, fn func(ctx context.Context) error) error {
+	conn, err := e.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	// Соединение с локом нельзя просто вернуть в пул: вместе с ним в пуле останется и сессия с локом
+	defer e.discard(conn)
+
+	var acquired bool
+	if err := conn.GetContext(ctx, &acquired, `SELECT pg_try_advisory_lock($1)`, e.lockID); err != nil {
+		return err
+	}
+
+	if !acquired {
+		return nil
+	}
+
+	log.Printf("[INFO] became leader (lock %d)", e.lockID)
+	defer log.Printf("[INFO] leadership lost (lock %d)", e.lockID)
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(leaderCtx)
+	}()
+
+	ticker := time.NewTicker(e.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ticker.C:
+			if err := e.check(ctx, conn); err != nil && ctx.Err() == nil {
+				// Соединение потеряно или не отвечает, а значит лок может быть уже у другого инстанса.
+				// Останавливаем воркеры и ждем их завершения
+				cancel()
+				<-done
+				return err
+			}
+		}
+	}
+}
+
+// Проверяет, что соединение с локом живо. Зависшее соединение не лучше оборванного:
+// если оно не ответило до следующей проверки, считаем лидерство потерянным
+func (e *Elector) check(ctx context.Context, conn *sqlx.Conn) error {
+	ctx, cancel := context.WithTimeout(ctx, e.checkInterval)
+	defer cancel()
+
+	_, err := conn.ExecContext(ctx, `SELECT 1`)
+	return err
+}
+
+// Отпускает лок и закрывает соединение.
+// Если снять лок не удалось, соединение выбрасывается из пула, чтобы Postgres завершил сессию и освободил лок
+func (e *Elector) discard(conn *sqlx.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock_all()`); err != nil {
+		_ = conn.Raw(func(any) error {
+			return driver.ErrBadConn
+		})
+	}
+
+	if err := conn.Close(); err != nil {
+		log.Printf("[ERROR] failed to close leader connection: %v", err)
+	}
+}
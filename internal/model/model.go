@@ -1,9 +1,17 @@
 package model
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// Захват статьи истек, и ее, возможно, уже обрабатывает другой инстанс
+var ErrClaimLost = errors.New("article claim is lost")
 
 // Статья как элемент ленты
 type Item struct {
+	// Уникальный идентификатор статьи в ленте (GUID)
+	GUID string
 	// Название статьи
 	Title string
 	// Категории статей
@@ -34,13 +42,244 @@ type Source struct {
 type Article struct {
 	ID       int64
 	SourceID int64
-	Title    string
-	Link     string
-	Summary  string
+	// Идентификатор статьи в ленте, по нему узнаем статью при повторном фетчинге
+	GUID    string
+	Title   string
+	Link    string
+	Summary string
+	// Категории статьи из ленты
+	Categories []string
+	// Язык статьи, например ru или en. Пустой, если язык определить не удалось
+	Language string
+	// Язык определен по полному тексту статьи. Такой язык точнее определенного по ленте и при обновлении статьи не меняется
+	LanguageDetected bool
+	// Теги из словаря, которые выбрала LLM, и время классификации. Нулевое время - статья еще не классифицирована
+	Tags     []string
+	TaggedAt time.Time
+	// Время и причина, по которой статья не попала в очередь, например из-за фильтра по тегам
+	SkippedAt  time.Time
+	SkipReason string
+	// Полный текст статьи закрыт paywall или извлечь его не удалось, а summary в ленте нет. Summary для такой статьи не генерируется
+	Paywalled bool
 	// Время публикации в источнике
 	PublishedAt time.Time
 	// Время публикации в телеграмм канале
 	PostedAt time.Time
 	// Время создания
 	CreatedAt time.Time
+	// Токен захвата статьи для отправки. Изменения, которые делает захвативший статью инстанс, проверяют, что захват все еще его
+	ClaimToken string
+	// Количество неудачных попыток отправки
+	Attempts int
+	// Текст последней ошибки при отправке
+	LastError string
+	// Время, раньше которого не нужно пытаться отправить статью снова
+	NextAttemptAt time.Time
+	// Время, когда статья была перенесена в dead-letter после исчерпания попыток
+	DeadAt time.Time
+	// Время, когда админ снял статью с публикации без возврата в очередь
+	DroppedAt time.Time
+	// Статус модерации. Пустой, если статья не проходила модерацию
+	ModerationStatus ModerationStatus
+	// Кто и когда принял решение по статье
+	ModeratedBy     int64
+	ModeratedByName string
+	ModeratedAt     time.Time
+	// Summary, исправленное редактором. Если задано, используется вместо сгенерированного
+	EditedSummary string
+	// Summary, с которым статья была опубликована. По нему строится исходящая лента
+	PublishedSummary string
+}
+
+// Статус статьи в очереди модерации
+type ModerationStatus string
+
+const (
+	// Статья отправлена редакторам и ждет решения
+	ModerationPending ModerationStatus = "pending"
+	// Статья одобрена и может быть опубликована
+	ModerationApproved ModerationStatus = "approved"
+	// Статья отклонена
+	ModerationRejected ModerationStatus = "rejected"
+	// Никто не принял решение вовремя
+	ModerationExpired ModerationStatus = "expired"
+)
+
+// Доставка статьи в одно из мест назначения (канал телеграма, вебхук и т.д.)
+type Delivery struct {
+	ArticleID int64
+	// Место назначения, например telegram или discord
+	Destination string
+	Status      DeliveryStatus
+	// Чат и сообщение, если место назначения - телеграм. По ним пост можно отредактировать или удалить
+	ChatID    int64
+	MessageID int
+	// Количество попыток доставки и последняя ошибка
+	Attempts    int
+	LastError   string
+	DeliveredAt time.Time
+}
+
+// Статус доставки статьи в место назначения
+type DeliveryStatus string
+
+const (
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Сгенерированное summary статьи. Вместе с текстом хранится, чем и из какого текста оно получено,
+// чтобы при повторной отправке не генерировать его заново и чтобы можно было проверить, что было опубликовано
+type Summary struct {
+	ArticleID int64
+	Text      string
+	// Структурированное summary: главная мысль одним предложением, ключевые факты и упомянутые сущности.
+	// Заполняется только в структурированном режиме, Text тогда содержит его текстовую версию
+	TLDR     string
+	Bullets  []string
+	Entities []string
+	// Модель, которая сгенерировала summary, например gpt-3.5-turbo или textrank
+	Model string
+	// Версия промпта: хэш текста промпта, с которым генерировалось summary
+	PromptVersion string
+	// sha256 текста статьи, по которому генерировалось summary
+	TextHash  string
+	CreatedAt time.Time
+}
+
+// Запрос на генерацию summary статьи
+type SummaryRequest struct {
+	ArticleID int64
+	SourceID  int64
+	// Очищенный от html текст статьи и его язык, пустой если язык не определен
+	Text          string
+	InputLanguage string
+	// Промпт для LLM. Пустой промпт означает промпт по умолчанию
+	Promt string
+	// Максимальная длина summary в символах и язык ответа. Нулевые значения означают, что ограничений нет
+	MaxLength int
+	Language  string
+}
+
+// Расход токенов на один запрос к LLM
+type Usage struct {
+	ArticleID        int64
+	SourceID         int64
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	// Оценка стоимости запроса в долларах по таблице цен
+	Cost      float64
+	CreatedAt time.Time
+}
+
+// Суммарный расход токенов за период, сгруппированный по дню или источнику
+type UsageTotal struct {
+	// День в формате 2006-01-02 или имя источника
+	Key              string
+	Requests         int
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// Настройки генерации summary для источника
+type SummarySettings struct {
+	SourceID int64
+	// Генерировать ли summary для статей источника. Если нет, посты уходят без summary
+	Enabled bool
+	// Шаблон промпта text/template с переменными .Title, .Source и .Language. Пустой шаблон означает промпт по умолчанию
+	PromptTemplate string
+	// Максимальная длина summary в символах, 0 - без ограничения
+	MaxLength int
+	// Язык, на котором нужно написать summary, например ru или en. Пустой - язык не указывается
+	Language string
+}
+
+// Перевод заголовка и summary статьи на язык места назначения
+type Translation struct {
+	ArticleID int64
+	Language  string
+	Title     string
+	Summary   string
+	// Перевод структурированного summary, пустые если summary обычное
+	TLDR     string
+	Bullets  []string
+	Entities []string
+	// sha256 исходных заголовка и summary. Если они поменялись, перевод нужно сделать заново
+	SourceHash string
+}
+
+// Запрос на перевод статьи
+type TranslationRequest struct {
+	ArticleID int64
+	SourceID  int64
+	Title     string
+	Summary   string
+	// Структурированное summary, если оно есть
+	TLDR     string
+	Bullets  []string
+	Entities []string
+	// Язык статьи, пустой если не определен, и язык, на который нужно перевести
+	From string
+	To   string
+}
+
+// Тег из словаря, который ведут админы
+type Tag struct {
+	Name string
+	// Описание помогает LLM понять, какие статьи подходят под тег
+	Description string
+	CreatedAt   time.Time
+}
+
+// Запрос на классификацию статьи по словарю тегов
+type ClassificationRequest struct {
+	ArticleID  int64
+	SourceID   int64
+	Title      string
+	Text       string
+	Vocabulary []Tag
+}
+
+// Редакционный бриф места назначения: описание того, какие статьи ему подходят.
+// Статьи с оценкой релевантности ниже Threshold туда не публикуются
+// Правило извлечения текста статей сайта, для которого readability работает плохо
+type ExtractionRule struct {
+	// Домен сайта, правило действует и на его поддомены
+	Domain string
+	// CSS селектор блока с текстом статьи. Пустой - текст выделяет readability
+	ContentSelector string
+	// CSS селекторы элементов, которые удаляются перед извлечением, например баннеры cookie и блоки подписки
+	StripSelectors []string
+	// CSS селектор элемента, который есть на странице только если статья закрыта paywall
+	PaywallSelector string
+	UpdatedAt       time.Time
+}
+
+type Brief struct {
+	Destination string
+	Text        string
+	Threshold   int
+	UpdatedAt   time.Time
+}
+
+// Запрос на оценку релевантности статьи брифу
+type RelevanceRequest struct {
+	ArticleID int64
+	SourceID  int64
+	Title     string
+	Text      string
+	Brief     string
+}
+
+// Оценка релевантности статьи брифу места назначения от 0 до 100 и короткое объяснение оценки
+type RelevanceScore struct {
+	ArticleID   int64
+	Destination string
+	Score       int
+	Reason      string
+	// sha256 текста брифа, по которому ставилась оценка. Если бриф поменялся, статью нужно оценить заново
+	BriefHash string
+	CreatedAt time.Time
 }
@@ -2,58 +2,231 @@ package notifier
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"github.com/go-shiori/go-readability"
-	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/lang"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
-	"io"
-	"net/http"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/page"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/publisher"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/render"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/route"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/summary"
+	"log"
 	"regexp"
 	"strings"
 	"time"
-
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 type ArticleProvider interface {
-	AllNotPosted(ctx context.Context, since time.Time, limit uint64) ([]model.Article, error)
-	MarkPosted(ctx context.Context, id int64) error
+	ClaimNext(ctx context.Context, since time.Time, lease time.Duration, status model.ModerationStatus) (*model.Article, error)
+	ExtendClaim(ctx context.Context, id int64, token string, lease time.Duration) error
+	Release(ctx context.Context, id int64, token string) error
+	MarkPosted(ctx context.Context, id int64, token string, summary string) error
+	SetPublishedSummary(ctx context.Context, id int64, summary string) error
+	MarkFailed(ctx context.Context, id int64, reason string, nextAttemptAt time.Time, maxAttempts int) (bool, error)
+	ArticleByID(ctx context.Context, id int64) (*model.Article, error)
+	ClaimEdit(ctx context.Context) (*model.Article, error)
+	Deliveries(ctx context.Context, articleID int64) ([]model.Delivery, error)
+	MarkDelivered(ctx context.Context, delivery model.Delivery, token string) error
+	MarkDeliveryFailed(ctx context.Context, articleID int64, token string, destination string, reason string) error
+	MarkSentForModeration(ctx context.Context, id int64, messageID int) error
+	ExpireModeration(ctx context.Context, before time.Time) (int64, error)
+	RequestEdit(ctx context.Context, id int64) error
+	SetLanguage(ctx context.Context, id int64, language string) error
+	SetPaywalled(ctx context.Context, id int64, paywalled bool) error
+	SetTags(ctx context.Context, id int64, tags []string) error
+	Skip(ctx context.Context, id int64, reason string) error
+}
+
+type SourceProvider interface {
+	SourceByID(ctx context.Context, id int64) (*model.Source, error)
+}
+
+// Загрузка страниц статей, у которых в ленте нет summary
+type PageFetcher interface {
+	Fetch(ctx context.Context, link string) (page.Page, error)
+}
+
+// Извлечение текста статьи со страницы и проверка, что текст годится для summary
+type TextExtractor interface {
+	Extract(ctx context.Context, p page.Page) (page.Content, error)
+	Check(text string) string
 }
 
 type Summarizer interface {
-	Summarize(ctx context.Context, text string) (string, error)
+	Summarize(ctx context.Context, req model.SummaryRequest) (model.Summary, error)
+}
+
+// Хранилище сгенерированных summary, чтобы не генерировать их заново при повторной отправке
+type SummaryStorage interface {
+	SummaryByArticleID(ctx context.Context, articleID int64) (*model.Summary, error)
+	Save(ctx context.Context, summary model.Summary) error
+	Settings(ctx context.Context, sourceID int64) (model.SummarySettings, error)
+}
+
+// Перевод заголовка и summary статьи на другой язык
+type Translator interface {
+	Translate(ctx context.Context, req model.TranslationRequest) (model.Translation, error)
+}
+
+// Хранилище переводов, чтобы не переводить статью заново при повторной отправке и редактировании
+type TranslationStorage interface {
+	Translation(ctx context.Context, articleID int64, language string) (*model.Translation, error)
+	SaveTranslation(ctx context.Context, translation model.Translation) error
+}
+
+// Классификация статьи по словарю тегов
+type Classifier interface {
+	Classify(ctx context.Context, req model.ClassificationRequest) ([]string, error)
+}
+
+// Оценка релевантности статьи редакционному брифу
+type Scorer interface {
+	Score(ctx context.Context, req model.RelevanceRequest) (model.RelevanceScore, error)
+}
+
+// Редакционные брифы мест назначения и сохраненные оценки релевантности
+type RelevanceStorage interface {
+	Briefs(ctx context.Context) ([]model.Brief, error)
+	Score(ctx context.Context, articleID int64, destination string) (*model.RelevanceScore, error)
+	SaveScore(ctx context.Context, score model.RelevanceScore) error
+}
+
+// Словарь тегов, который ведут админы
+type TagProvider interface {
+	Tags(ctx context.Context) ([]model.Tag, error)
+}
+
+// Место назначения, куда публикуются статьи: канал телеграма, Discord, Slack, вебхук.
+// Каждое место назначения само форматирует статью в своей разметке
+type Publisher interface {
+	// Имя места назначения, под ним сохраняется статус доставки
+	Name() string
+	// Публикует статью. В возвращенной доставке заполняются только данные отправленного сообщения, если они есть
+	Publish(ctx context.Context, article model.Article, source model.Source, post render.Post) (model.Delivery, error)
+}
+
+// Место назначения, в котором можно отредактировать уже опубликованный пост
+type Editor interface {
+	Edit(ctx context.Context, delivery model.Delivery, article model.Article, source model.Source, post render.Post) error
+}
+
+// Отправка статей редакторам на модерацию. Возвращает id сообщения в чате редакторов
+type ModerationSender interface {
+	SendForModeration(ctx context.Context, article model.Article, source model.Source, post render.Post) (int, error)
 }
 
 type Notifier struct {
 	// Провайдер для статей
 	articles ArticleProvider
+	// Провайдер источников, нужен чтобы показать в посте имя источника
+	sources SourceProvider
+	// Загрузчик страниц статей
+	pages PageFetcher
+	// Извлечение текста статьи со страницы
+	extractor TextExtractor
 	// Компонент, который будет генерить summary
 	summarizer Summarizer
-	// Инстанс клиента botAPI
-	bot *tgbotapi.BotAPI
+	// Сохраненные summary
+	summaries SummaryStorage
+	// Переводчик заголовков и summary. Если nil, статьи публикуются на языке оригинала
+	translator Translator
+	// Сохраненные переводы
+	translations TranslationStorage
+	// Язык, на котором публикуются статьи в каждом месте назначения
+	targetLanguages map[string]string
+	// Классификатор статей по словарю тегов. Если nil, теги не проставляются
+	classifier Classifier
+	// Словарь тегов
+	tags TagProvider
+	// Теги, статьи с которыми не публикуются
+	filterTags []string
+	// Языки, статьи на которых публикуются. Пустой список - любые языки
+	filterLanguages []string
+	// Языки и теги статей, которые принимает место назначения. Места назначения без правил принимают любые статьи
+	languageRoutes route.Routes
+	tagRoutes      route.Routes
+	// Оценка релевантности статей брифам мест назначения. Если nil, статьи не оцениваются
+	scorer Scorer
+	// Брифы и сохраненные оценки
+	relevance RelevanceStorage
+	// Шаблоны постов, нужны для предпросмотра
+	renderer *render.Renderer
+	// Места назначения, куда публикуются статьи
+	publishers []Publisher
+	// Отправка статей на модерацию. Если nil, статьи публикуются сразу
+	moderation ModerationSender
 	// Интервал, с которым notifier будет проверять есть ли новые статьи
 	sendInterval time.Duration
 	// Время в прошлое, в которое будет заглядываться notifier, чтобы узнать есть за этот период новые статьи
 	lookupTimeWindow time.Duration
-	// id канала куда мы будем постить статьи
-	channelID int64
+	// Сколько раз пытаемся отправить статью, прежде чем перенести ее в dead-letter. 0 - без ограничения
+	maxAttempts int
+	// Базовая задержка перед повторной попыткой отправки
+	retryBackoff time.Duration
+	// На какое время статья захватывается инстансом для отправки
+	claimLease time.Duration
+	// Через какое время статья, по которой никто не принял решения, снимается с модерации
+	moderationTimeout time.Duration
 }
 
 func New(
 	articleProvider ArticleProvider,
+	sourceProvider SourceProvider,
+	pages PageFetcher,
+	extractor TextExtractor,
 	summarizer Summarizer,
-	bot *tgbotapi.BotAPI,
+	summaries SummaryStorage,
+	translator Translator,
+	translations TranslationStorage,
+	targetLanguages map[string]string,
+	classifier Classifier,
+	tags TagProvider,
+	filterTags []string,
+	filterLanguages []string,
+	languageRoutes route.Routes,
+	tagRoutes route.Routes,
+	scorer Scorer,
+	relevance RelevanceStorage,
+	renderer *render.Renderer,
+	publishers []Publisher,
+	moderation ModerationSender,
 	sendInterval time.Duration,
 	lookupTimeWindow time.Duration,
-	channelID int64,
+	maxAttempts int,
+	retryBackoff time.Duration,
+	claimLease time.Duration,
+	moderationTimeout time.Duration,
 ) *Notifier {
 	return &Notifier{
-		articles:         articleProvider,
-		summarizer:       summarizer,
-		bot:              bot,
-		sendInterval:     sendInterval,
-		lookupTimeWindow: lookupTimeWindow,
-		channelID:        channelID,
+		articles:          articleProvider,
+		sources:           sourceProvider,
+		pages:             pages,
+		extractor:         extractor,
+		summarizer:        summarizer,
+		summaries:         summaries,
+		translator:        translator,
+		translations:      translations,
+		targetLanguages:   targetLanguages,
+		classifier:        classifier,
+		tags:              tags,
+		filterTags:        filterTags,
+		filterLanguages:   filterLanguages,
+		languageRoutes:    languageRoutes,
+		tagRoutes:         tagRoutes,
+		scorer:            scorer,
+		relevance:         relevance,
+		renderer:          renderer,
+		publishers:        publishers,
+		moderation:        moderation,
+		sendInterval:      sendInterval,
+		lookupTimeWindow:  lookupTimeWindow,
+		maxAttempts:       maxAttempts,
+		retryBackoff:      retryBackoff,
+		claimLease:        claimLease,
+		moderationTimeout: moderationTimeout,
 	}
 }
 
@@ -61,111 +234,923 @@ func (n *Notifier) Start(ctx context.Context) error {
 	ticker := time.NewTicker(n.sendInterval)
 	defer ticker.Stop()
 
-	if err := n.SelectAndSendArticle(ctx); err != nil {
-		return err
-	}
+	// Ошибки отдельной итерации не должны останавливать notifier, поэтому только логируем их
+	n.tick(ctx)
 
 	for {
 		select {
 		case <-ticker.C:
-			if err := n.SelectAndSendArticle(ctx); err != nil {
-				return err
-			}
+			n.tick(ctx)
 		case <-ctx.Done():
 			return ctx.Err()
 		}
 	}
 }
 
-// Метод для выборки и отправки статьи
+// Одна итерация notifier: отправка статьи на модерацию, публикация новой статьи и редактирование поста с изменившейся статьей
+func (n *Notifier) tick(ctx context.Context) {
+	if n.moderation != nil {
+		if err := n.SelectAndSendForModeration(ctx); err != nil {
+			log.Printf("[ERROR] failed to send article for moderation: %v", err)
+		}
+
+		if err := n.expireModeration(ctx); err != nil {
+			log.Printf("[ERROR] failed to expire moderation: %v", err)
+		}
+	}
+
+	if err := n.SelectAndSendArticle(ctx); err != nil {
+		log.Printf("[ERROR] failed to select and send article: %v", err)
+	}
+
+	if err := n.EditChangedArticle(ctx); err != nil {
+		log.Printf("[ERROR] failed to edit changed article: %v", err)
+	}
+}
+
+// Метод для выборки и отправки статьи во все места назначения
 func (n *Notifier) SelectAndSendArticle(ctx context.Context) error {
-	topOneArticles, err := n.articles.AllNotPosted(ctx, time.Now().Add(-n.lookupTimeWindow), 1)
-	// Заврапить!
-	if err != nil {
-		return err
+	// Если включена модерация, публикуем только одобренные редакторами статьи
+	var status model.ModerationStatus
+	if n.moderation != nil {
+		status = model.ModerationApproved
 	}
 
-	// ОБЕРНУТЬ ВСЕ ДЕЙСТВИЯ ЗДЕСБ В ТРАНЗАКЦИЮ
+	// Захватываем статью, чтобы другие инстансы бота не отправили ее параллельно с нами
+	article, err := n.articles.ClaimNext(ctx, time.Now().Add(-n.lookupTimeWindow), n.claimLease, status)
+	if err != nil {
+		return fmt.Errorf("claim article: %w", err)
+	}
 
 	// Если нет статьи, то ничего не делаем
-	if len(topOneArticles) == 0 {
+	if article == nil {
 		return nil
 	}
 
-	article := topOneArticles[0]
+	source, err := n.sources.SourceByID(ctx, article.SourceID)
+	if err != nil {
+		return n.handleFailure(ctx, *article, fmt.Errorf("get source %d: %w", article.SourceID, err))
+	}
+
+	text, err := n.analyze(ctx, article)
+	if err != nil {
+		return n.handleFailure(ctx, *article, err)
+	}
+
+	rejected, err := n.score(ctx, *article, text)
+	if err != nil {
+		return n.handleFailure(ctx, *article, err)
+	}
+
+	if reason := n.skipReason(*article, rejected); reason != "" {
+		return n.skip(ctx, *article, reason)
+	}
+
+	post, err := n.preparePost(ctx, *article, *source, text)
+	if err != nil {
+		return n.handleFailure(ctx, *article, err)
+	}
+
+	if err := n.publish(ctx, *article, *source, post, rejected); err != nil {
+		return n.handleFailure(ctx, *article, err)
+	}
+
+	// После того, как статья доставлена во все места назначения, отмечаем ее, как запощенную
+	if err := n.articles.MarkPosted(ctx, article.ID, article.ClaimToken, post.Summary); err != nil {
+		return n.handleFailure(ctx, *article, fmt.Errorf("mark article posted: %w", err))
+	}
+
+	return nil
+}
+
+// Публикует статью во все места назначения, куда она еще не была доставлена.
+// При повторной попытке места назначения, где статья уже опубликована, пропускаются, чтобы не было дублей.
+// Места назначения, которым статья не подходит по языку, тегам или оценке релевантности из rejected, тоже пропускаются
+func (n *Notifier) publish(
+	ctx context.Context,
+	article model.Article,
+	source model.Source,
+	post render.Post,
+	rejected map[string]string,
+) error {
+	deliveries, err := n.articles.Deliveries(ctx, article.ID)
+	if err != nil {
+		return fmt.Errorf("get deliveries: %w", err)
+	}
+
+	delivered := make(map[string]bool, len(deliveries))
+	for _, delivery := range deliveries {
+		delivered[delivery.Destination] = delivery.Status == model.DeliveryDelivered
+	}
+
+	var failures []string
+
+	for _, publisher := range n.publishers {
+		if delivered[publisher.Name()] || n.rejection(article, publisher.Name(), rejected) != "" {
+			continue
+		}
+
+		localized := n.localize(ctx, article, post, publisher.Name())
+
+		// Подготовка статьи могла занять больше времени, чем длится захват: загрузка страницы, запросы к LLM, ожидание лимитов.
+		// Продлеваем захват перед каждой отправкой, а если его уже забрал другой инстанс, не публикуем, чтобы не было дублей
+		if err := n.articles.ExtendClaim(ctx, article.ID, article.ClaimToken, n.claimLease); err != nil {
+			return fmt.Errorf("extend claim before publishing to %s: %w", publisher.Name(), err)
+		}
+
+		delivery, err := publisher.Publish(ctx, article, source, localized)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", publisher.Name(), err))
+
+			if err := n.articles.MarkDeliveryFailed(ctx, article.ID, article.ClaimToken, publisher.Name(), err.Error()); err != nil {
+				if errors.Is(err, model.ErrClaimLost) {
+					return fmt.Errorf("save delivery status to %s: %w", publisher.Name(), err)
+				}
+				log.Printf("[ERROR] failed to save delivery status of article %d to %s: %v", article.ID, publisher.Name(), err)
+			}
+			continue
+		}
+
+		delivery.ArticleID = article.ID
+		delivery.Destination = publisher.Name()
+
+		if err := n.articles.MarkDelivered(ctx, delivery, article.ClaimToken); err != nil {
+			return fmt.Errorf("save delivery to %s: %w", publisher.Name(), err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("publish article: %s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// Метод для выборки статьи и отправки ее редакторам на модерацию.
+// Редакторы видят пост в том виде, в котором он уйдет в канал, и кнопки с решениями
+func (n *Notifier) SelectAndSendForModeration(ctx context.Context) error {
+	article, err := n.articles.ClaimNext(ctx, time.Now().Add(-n.lookupTimeWindow), n.claimLease, "")
+	if err != nil {
+		return fmt.Errorf("claim article: %w", err)
+	}
+
+	if article == nil {
+		return nil
+	}
+
+	source, err := n.sources.SourceByID(ctx, article.SourceID)
+	if err != nil {
+		return n.handleFailure(ctx, *article, fmt.Errorf("get source %d: %w", article.SourceID, err))
+	}
+
+	text, err := n.analyze(ctx, article)
+	if err != nil {
+		return n.handleFailure(ctx, *article, err)
+	}
 
-	summary, err := n.extractSummary(ctx, article)
+	rejected, err := n.score(ctx, *article, text)
+	if err != nil {
+		return n.handleFailure(ctx, *article, err)
+	}
+
+	// Статьи, которые все равно не будут опубликованы, редакторам не показываем
+	if reason := n.skipReason(*article, rejected); reason != "" {
+		return n.skip(ctx, *article, reason)
+	}
+
+	post, err := n.preparePost(ctx, *article, *source, text)
+	if err != nil {
+		return n.handleFailure(ctx, *article, err)
+	}
+
+	if err := n.articles.ExtendClaim(ctx, article.ID, article.ClaimToken, n.claimLease); err != nil {
+		return n.handleFailure(ctx, *article, fmt.Errorf("extend claim before moderation: %w", err))
+	}
+
+	// Редакторы видят пост на языке канала телеграма
+	messageID, err := n.moderation.SendForModeration(ctx, *article, *source, n.localize(ctx, *article, post, publisher.TelegramName))
+	if err != nil {
+		return n.handleFailure(ctx, *article, fmt.Errorf("send article for moderation: %w", err))
+	}
+
+	return n.articles.MarkSentForModeration(ctx, article.ID, messageID)
+}
+
+// Снимает с модерации статьи, по которым никто не принял решения за moderationTimeout
+func (n *Notifier) expireModeration(ctx context.Context) error {
+	expired, err := n.articles.ExpireModeration(ctx, time.Now().Add(-n.moderationTimeout))
 	if err != nil {
 		return err
 	}
 
-	if err := n.sendArticle(article, summary); err != nil {
+	if expired > 0 {
+		log.Printf("[INFO] %d articles expired in moderation queue", expired)
+	}
+
+	return nil
+}
+
+// Редактирует посты, если статья изменилась в источнике после публикации.
+// Редактируются только места назначения, которые это поддерживают
+func (n *Notifier) EditChangedArticle(ctx context.Context) error {
+	article, err := n.articles.ClaimEdit(ctx)
+	if err != nil {
+		return fmt.Errorf("claim article for edit: %w", err)
+	}
+
+	if article == nil {
+		return nil
+	}
+
+	deliveries, err := n.articles.Deliveries(ctx, article.ID)
+	if err != nil {
 		return err
 	}
 
-	// После того, как все получилось, отмечаем статью, как запощенную
-	return n.articles.MarkPosted(ctx, article.ID)
-	//summary, err := n.summarizer.Summarize(ctx, article.Summary)
+	source, err := n.sources.SourceByID(ctx, article.SourceID)
+	if err != nil {
+		return err
+	}
+
+	text, err := n.analyze(ctx, article)
+	if err != nil {
+		return fmt.Errorf("analyze article %d: %w", article.ID, err)
+	}
+
+	post, err := n.preparePost(ctx, *article, *source, text)
+	if err != nil {
+		return fmt.Errorf("prepare post of article %d: %w", article.ID, err)
+	}
+
+	for _, delivery := range deliveries {
+		if delivery.Status != model.DeliveryDelivered {
+			continue
+		}
+
+		editor, ok := n.publisher(delivery.Destination).(Editor)
+		if !ok {
+			continue
+		}
+
+		if err := editor.Edit(ctx, delivery, *article, *source, n.localize(ctx, *article, post, delivery.Destination)); err != nil {
+			return fmt.Errorf("edit post of article %d in %s: %w", article.ID, delivery.Destination, err)
+		}
+
+		log.Printf("[INFO] post of article %d in %s edited", article.ID, delivery.Destination)
+	}
+
+	// Исходящая лента должна показывать тот же summary, что и отредактированный пост
+	return n.articles.SetPublishedSummary(ctx, article.ID, post.Summary)
+}
+
+// Причина, по которой место назначения destination не принимает статью. Пустая строка - принимает.
+// Статьи, язык которых определить не удалось, уходят во все места назначения, а статьи без тегов - только туда, где нет правил по тегам.
+// rejected - места назначения, которым статья не подошла по оценке релевантности
+func (n *Notifier) rejection(article model.Article, destination string, rejected map[string]string) string {
+	if article.Language != "" && !n.languageRoutes.Allows(destination, article.Language) {
+		return fmt.Sprintf("language %s is not accepted", article.Language)
+	}
+
+	if !n.tagRoutes.Allows(destination, article.Tags...) {
+		return fmt.Sprintf("tags [%s] are not accepted", strings.Join(article.Tags, ", "))
+	}
+
+	return rejected[destination]
 }
 
-// Краткое содержание выдержки
-// Есть у статьи есть summary, то мы будем использовать этот текст для gpt
-// Если summary не заполнено, то мы идем по link, получаем html код страницы со статьей, и на основе этой страницы получить summary
-func (n *Notifier) extractSummary(ctx context.Context, article model.Article) (string, error) {
-	// Reader из которого мы в итоге будем читать summary
-	var r io.Reader
+func (n *Notifier) publisher(name string) Publisher {
+	for _, publisher := range n.publishers {
+		if publisher.Name() == name {
+			return publisher
+		}
+	}
+
+	return nil
+}
+
+// Фиксирует неудачную попытку отправки статьи.
+// Следующая попытка откладывается с экспоненциальной задержкой, а после maxAttempts статья уходит в dead-letter.
+// Сама ошибка отправки наружу не возвращается, чтобы одна проблемная статья не останавливала весь конвейер
+func (n *Notifier) handleFailure(ctx context.Context, article model.Article, cause error) error {
+	// Захват истек, и статью уже обрабатывает другой инстанс. Ее состояние теперь меняет он
+	if errors.Is(cause, model.ErrClaimLost) {
+		log.Printf("[WARN] article %d was claimed by another instance, abandoning it: %v", article.ID, cause)
+		return nil
+	}
+
+	// Если нас остановили, это не вина статьи: просто отпускаем ее, не расходуя попытку
+	if ctx.Err() != nil {
+		if err := n.articles.Release(context.Background(), article.ID, article.ClaimToken); err != nil {
+			log.Printf("[ERROR] failed to release article %d: %v", article.ID, err)
+		}
+		return ctx.Err()
+	}
+
+	// Ограничиваем сдвиг, чтобы задержка не переполнилась при большом maxAttempts
+	shift := article.Attempts
+	if shift > 10 {
+		shift = 10
+	}
+	nextAttemptAt := time.Now().Add(n.retryBackoff << shift)
 
-	if article.Summary != "" {
-		r = strings.NewReader(article.Summary)
-	} else {
-		// Настроить retry, back off
-		resp, err := http.Get(article.Link)
+	dead, err := n.articles.MarkFailed(ctx, article.ID, cause.Error(), nextAttemptAt, n.maxAttempts)
+	if err != nil {
+		return fmt.Errorf("mark article %d as failed: %w", article.ID, err)
+	}
+
+	if dead {
+		log.Printf("[ERROR] article %d moved to dead-letter after %d attempts: %v", article.ID, article.Attempts+1, cause)
+		return nil
+	}
+
+	log.Printf("[WARN] article %d failed (attempt %d), next attempt at %s: %v", article.ID, article.Attempts+1, nextAttemptAt.Format(time.RFC3339), cause)
+	return nil
+}
+
+// Краткое содержание выдержки по тексту статьи text.
+// Текст берется из summary статьи, а если оно не заполнено - со страницы статьи (см. articleText).
+// Пустое summary означает, что пост уходит без него
+func (n *Notifier) extractSummary(ctx context.Context, article model.Article, source model.Source, text string) (model.Summary, error) {
+	// Если редактор исправил summary, генерировать его заново не нужно
+	if article.EditedSummary != "" {
+		return model.Summary{ArticleID: article.ID, Text: article.EditedSummary}, nil
+	}
+
+	settings, err := n.summaries.Settings(ctx, source.ID)
+	if err != nil {
+		return model.Summary{}, fmt.Errorf("get summary settings: %w", err)
+	}
+
+	// Для источника summary отключено, например потому что статьи в нем и так короткие
+	if !settings.Enabled {
+		return model.Summary{}, nil
+	}
+
+	// Если summary уже генерировали по этому же тексту, например при прошлой неудачной попытке отправки, используем его
+	hash := textHash(text)
+
+	saved, err := n.summaries.SummaryByArticleID(ctx, article.ID)
+	if err != nil {
+		return model.Summary{}, fmt.Errorf("get saved summary: %w", err)
+	}
+
+	if saved != nil && saved.TextHash == hash {
+		return *saved, nil
+	}
+
+	// Полного текста нет, а пересказ тизера или заглушки paywall только введет читателей в заблуждение.
+	// Сохраненное summary выше при этом используется: его мог явно запросить админ через /resummarize
+	if article.Paywalled {
+		return model.Summary{}, nil
+	}
+
+	summary, err := n.summarize(ctx, article, source, settings, text, "")
+	if err != nil {
+		return model.Summary{}, err
+	}
+
+	return summary, nil
+}
+
+// Генерирует summary по настройкам источника и сохраняет его.
+// Непустой promt заменяет шаблон промпта источника, а если нет ни того, ни другого, используется промпт по умолчанию
+func (n *Notifier) summarize(
+	ctx context.Context,
+	article model.Article,
+	source model.Source,
+	settings model.SummarySettings,
+	text string,
+	promt string,
+) (model.Summary, error) {
+	if promt == "" && settings.PromptTemplate != "" {
+		rendered, err := summary.RenderPrompt(settings.PromptTemplate, summary.PromptData{
+			Title:         article.Title,
+			Source:        source.Name,
+			Language:      settings.Language,
+			InputLanguage: article.Language,
+		})
 		if err != nil {
-			return "", err
+			return model.Summary{}, fmt.Errorf("render prompt of source %d: %w", source.ID, err)
 		}
-		defer resp.Body.Close()
+		promt = rendered
+	}
+
+	generated, err := n.summarizer.Summarize(ctx, model.SummaryRequest{
+		ArticleID:     article.ID,
+		SourceID:      article.SourceID,
+		Text:          text,
+		InputLanguage: article.Language,
+		Promt:         promt,
+		MaxLength:     settings.MaxLength,
+		Language:      settings.Language,
+	})
+	// Пока LLM недоступна, не задерживаем публикацию: пост уходит без summary
+	if errors.Is(err, summary.ErrCircuitOpen) {
+		log.Printf("[WARN] summarizer is paused, article %d will be posted without summary", article.ID)
+		return model.Summary{}, nil
+	}
+	if err != nil {
+		return model.Summary{}, err
+	}
+
+	// Пустое summary не сохраняем: если summarizer выключен, при следующей попытке он может уже работать
+	if generated.Text == "" {
+		return model.Summary{}, nil
+	}
+
+	generated.ArticleID = article.ID
+	generated.TextHash = textHash(text)
 
-		r = resp.Body
+	if err := n.summaries.Save(ctx, generated); err != nil {
+		return model.Summary{}, fmt.Errorf("save summary: %w", err)
 	}
 
-	// Преобразуем наш reader в документ
-	doc, err := readability.FromReader(r, nil)
+	return generated, nil
+}
+
+// Генерирует summary статьи заново с промптом promt, даже если сохраненное summary еще актуально.
+// Если статья уже опубликована, пост будет отредактирован. Исправленное редактором summary по-прежнему важнее сгенерированного
+func (n *Notifier) Resummarize(ctx context.Context, articleID int64, promt string) (string, error) {
+	article, err := n.articles.ArticleByID(ctx, articleID)
+	if err != nil {
+		return "", err
+	}
+
+	text, err := n.analyze(ctx, article)
+	if err != nil {
+		return "", err
+	}
+
+	source, err := n.sources.SourceByID(ctx, article.SourceID)
+	if err != nil {
+		return "", err
+	}
+
+	// Отключенное для источника summary не мешает админу сгенерировать его явно
+	settings, err := n.summaries.Settings(ctx, source.ID)
 	if err != nil {
 		return "", err
 	}
 
-	// Получаем summary
-	summary, err := n.summarizer.Summarize(ctx, cleanText(doc.TextContent))
+	generated, err := n.summarize(ctx, *article, *source, settings, text, promt)
 	if err != nil {
 		return "", err
 	}
 
-	return "\n\n" + summary, nil
+	if err := n.articles.RequestEdit(ctx, article.ID); err != nil {
+		return "", err
+	}
+
+	return generated.Text, nil
 }
 
-// Метод отправки статьи
-func (n *Notifier) sendArticle(article model.Article, summary string) error {
-	// Шаблон сообщения. Сначала идет жирным заголовок, потом summary, потом ссылка на статью
-	const msgFormat = "*%s*%s\n\n%s"
+// Текст статьи без html. Берется из summary в ленте, а если там только тизер - со страницы статьи.
+// Summary из ленты - это уже сам контент, поэтому из него просто убираются теги.
+// Если со страницы не удалось получить нормальный текст или сама страница недоступна, используется тизер из ленты,
+// а если нет и его, возвращается то, что удалось извлечь, и признак того, что статья закрыта paywall
+func (n *Notifier) articleText(ctx context.Context, article model.Article) (string, bool, error) {
+	feedText := cleanText(page.Text(article.Summary))
 
-	msg := tgbotapi.NewMessage(n.channelID, fmt.Sprintf(
-		// Т.к. используется markdown верстка и некоторые спец символы из markdown используются как обычные символы
-		// Поэтому надо обернуть аргументы в escape
-		msgFormat,
-		markup.EscapeForMarkdown(article.Title),
-		markup.EscapeForMarkdown(summary),
-		markup.EscapeForMarkdown(article.Link),
-	))
-	// Даем понять телеграм, чтобы это сообщение парсилось как markdown сообщение
-	msg.ParseMode = tgbotapi.ModeMarkdownV2
+	if feedText != "" && n.extractor.Check(feedText) == "" {
+		return feedText, false, nil
+	}
 
-	// Отправляем сообщение
-	_, err := n.bot.Send(msg)
+	content, err := n.pageText(ctx, article)
 	if err != nil {
-		return err
+		if feedText != "" {
+			log.Printf("[WARN] failed to get text of article %d from page, using feed summary: %v", article.ID, err)
+			return feedText, false, nil
+		}
+
+		// Страница недоступна насовсем, например сайт отвечает 403. Повторные попытки ничего не дадут,
+		// поэтому публикуем статью без summary, как и статью без нормального текста
+		if page.Permanent(err) {
+			log.Printf("[WARN] article %d page is unavailable and is marked as paywalled: %v", article.ID, err)
+			return "", true, nil
+		}
+
+		return "", false, err
+	}
+
+	if content.Problem == "" {
+		return cleanText(content.Text), false, nil
+	}
+
+	if feedText != "" {
+		log.Printf("[WARN] text of article %d extracted from page is unusable (%s), using feed summary", article.ID, content.Problem)
+		return feedText, false, nil
+	}
+
+	log.Printf("[WARN] article %d has no usable text and is marked as paywalled: %s", article.ID, content.Problem)
+	return cleanText(content.Text), true, nil
+}
+
+// Загружает страницу статьи и извлекает из нее текст
+func (n *Notifier) pageText(ctx context.Context, article model.Article) (page.Content, error) {
+	articlePage, err := n.pages.Fetch(ctx, article.Link)
+	if err != nil {
+		return page.Content{}, fmt.Errorf("fetch article page: %w", err)
+	}
+
+	content, err := n.extractor.Extract(ctx, articlePage)
+	if err != nil {
+		return page.Content{}, fmt.Errorf("extract article text: %w", err)
+	}
+
+	return content, nil
+}
+
+// Получает текст статьи и уточняет по нему язык и теги статьи, а также доступен ли полный текст.
+// Найденные язык, теги и признак paywall записываются в article
+func (n *Notifier) analyze(ctx context.Context, article *model.Article) (string, error) {
+	text, paywalled, err := n.articleText(ctx, *article)
+	if err != nil {
+		return "", err
+	}
+
+	if paywalled != article.Paywalled {
+		if err := n.articles.SetPaywalled(ctx, article.ID, paywalled); err != nil {
+			return "", fmt.Errorf("save paywall flag of article %d: %w", article.ID, err)
+		}
+		article.Paywalled = paywalled
+	}
+
+	if err := n.detectLanguage(ctx, article, text); err != nil {
+		return "", err
+	}
+
+	if err := n.classify(ctx, article, text); err != nil {
+		return "", err
+	}
+
+	return text, nil
+}
+
+// Проставляет статье теги из словаря, если она еще не классифицирована. Каждая статья классифицируется один раз.
+// Если LLM недоступна, статья публикуется без тегов, а классифицировать ее попробуем при следующей обработке
+func (n *Notifier) classify(ctx context.Context, article *model.Article, text string) error {
+	if n.classifier == nil || !article.TaggedAt.IsZero() {
+		return nil
+	}
+
+	vocabulary, err := n.tags.Tags(ctx)
+	if err != nil {
+		return fmt.Errorf("get tags: %w", err)
+	}
+
+	// Пока словарь пуст, классифицировать не по чему, и отмечать статью классифицированной рано
+	if len(vocabulary) == 0 {
+		return nil
+	}
+
+	tags, err := n.classifier.Classify(ctx, model.ClassificationRequest{
+		ArticleID:  article.ID,
+		SourceID:   article.SourceID,
+		Title:      article.Title,
+		Text:       text,
+		Vocabulary: vocabulary,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		log.Printf("[WARN] failed to classify article %d, it will be posted without tags: %v", article.ID, err)
+		return nil
+	}
+
+	if err := n.articles.SetTags(ctx, article.ID, tags); err != nil {
+		return fmt.Errorf("save tags of article %d: %w", article.ID, err)
+	}
+
+	article.Tags = tags
+	article.TaggedAt = time.Now()
+	return nil
+}
+
+// Причина не публиковать статью. Пустая строка - статью можно публиковать
+func (n *Notifier) skipReason(article model.Article, rejected map[string]string) string {
+	for _, tag := range article.Tags {
+		for _, filtered := range n.filterTags {
+			if strings.EqualFold(tag, filtered) {
+				return fmt.Sprintf("filtered by tag %s", tag)
+			}
+		}
+	}
+
+	// При фетчинге язык проверяется только по заголовку и описанию из ленты и часто не определяется,
+	// поэтому проверяем его еще раз после определения по полному тексту
+	if n.languageFiltered(article.Language) {
+		return fmt.Sprintf("filtered by language %s", article.Language)
+	}
+
+	reasons := make([]string, 0, len(n.publishers))
+	for _, publisher := range n.publishers {
+		reason := n.rejection(article, publisher.Name(), rejected)
+		if reason == "" {
+			return ""
+		}
+
+		reasons = append(reasons, fmt.Sprintf("%s: %s", publisher.Name(), reason))
+	}
+
+	return "no destination accepts article: " + strings.Join(reasons, "; ")
+}
+
+// Оценивает релевантность статьи брифам мест назначения и возвращает места назначения, которым статья не подходит, с причинами.
+// Оценка сохраняется и переиспользуется, пока не поменяется бриф. Порог сравнивается с текущим брифом.
+// Если оценить статью не удалось, она не отсеивается: лучше опубликовать лишнее, чем задержать публикацию
+func (n *Notifier) score(ctx context.Context, article model.Article, text string) (map[string]string, error) {
+	if n.scorer == nil {
+		return nil, nil
+	}
+
+	briefs, err := n.relevance.Briefs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get briefs: %w", err)
+	}
+
+	rejected := make(map[string]string)
+
+	for _, brief := range briefs {
+		if n.publisher(brief.Destination) == nil {
+			continue
+		}
+
+		hash := textHash(brief.Text)
+
+		score, err := n.relevance.Score(ctx, article.ID, brief.Destination)
+		if err != nil {
+			return nil, fmt.Errorf("get relevance score: %w", err)
+		}
+
+		if score == nil || score.BriefHash != hash {
+			generated, err := n.scorer.Score(ctx, model.RelevanceRequest{
+				ArticleID: article.ID,
+				SourceID:  article.SourceID,
+				Title:     article.Title,
+				Text:      text,
+				Brief:     brief.Text,
+			})
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+
+				log.Printf("[WARN] failed to score article %d for %s, it is accepted without score: %v", article.ID, brief.Destination, err)
+				continue
+			}
+
+			generated.ArticleID = article.ID
+			generated.Destination = brief.Destination
+			generated.BriefHash = hash
+
+			if err := n.relevance.SaveScore(ctx, generated); err != nil {
+				return nil, fmt.Errorf("save relevance score: %w", err)
+			}
+
+			score = &generated
+		}
+
+		if score.Score < brief.Threshold {
+			rejected[brief.Destination] = fmt.Sprintf("relevance %d < %d: %s", score.Score, brief.Threshold, score.Reason)
+		}
+	}
+
+	return rejected, nil
+}
+
+// Убирает статью из очереди, она не будет ни опубликована, ни отправлена на модерацию
+func (n *Notifier) skip(ctx context.Context, article model.Article, reason string) error {
+	if err := n.articles.Skip(ctx, article.ID, reason); err != nil {
+		return fmt.Errorf("skip article %d: %w", article.ID, err)
+	}
+
+	log.Printf("[INFO] article %d skipped: %s", article.ID, reason)
+	return nil
+}
+
+// Определяет язык статьи по заголовку и тексту и сохраняет его с отметкой, что он определен по тексту.
+// Если по тексту язык определить не удалось, остается прежний
+func (n *Notifier) detectLanguage(ctx context.Context, article *model.Article, text string) error {
+	language := lang.Detect(article.Title + "\n" + text)
+	if language == "" || (language == article.Language && article.LanguageDetected) {
+		return nil
+	}
+
+	if err := n.articles.SetLanguage(ctx, article.ID, language); err != nil {
+		return fmt.Errorf("save language of article %d: %w", article.ID, err)
 	}
+
+	article.Language = language
+	article.LanguageDetected = true
 	return nil
+}
+
+// Статьи, язык которых определить не удалось, не отфильтровываются, как и при фетчинге
+func (n *Notifier) languageFiltered(language string) bool {
+	if len(n.filterLanguages) == 0 || language == "" {
+		return false
+	}
+
+	for _, allowed := range n.filterLanguages {
+		if strings.EqualFold(allowed, language) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func textHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Готовит данные поста по тексту статьи: summary, время чтения и метаинформацию об источнике.
+// Дальше каждое место назначения форматирует их по-своему
+func (n *Notifier) preparePost(ctx context.Context, article model.Article, source model.Source, text string) (render.Post, error) {
+	generated, err := n.extractSummary(ctx, article, source, text)
+	if err != nil {
+		return render.Post{}, fmt.Errorf("extract summary: %w", err)
+	}
+
+	return newPost(article, source, text, generated), nil
+}
+
+func newPost(article model.Article, source model.Source, text string, generated model.Summary) render.Post {
+	return render.Post{
+		ID:               article.ID,
+		Title:            article.Title,
+		Link:             article.Link,
+		Summary:          generated.Text,
+		TLDR:             generated.TLDR,
+		Bullets:          generated.Bullets,
+		Entities:         generated.Entities,
+		Paywalled:        article.Paywalled,
+		SourceName:       source.Name,
+		Categories:       article.Categories,
+		Tags:             article.Tags,
+		PublishedAt:      article.PublishedAt,
+		ReadingTime:      render.ReadingTime(text),
+		OriginalTitle:    article.Title,
+		Language:         article.Language,
+		OriginalLanguage: article.Language,
+	}
+}
+
+// Переводит заголовок и summary поста на язык места назначения destination, если статья написана на другом языке.
+// Перевод сохраняется и переиспользуется, пока не поменяются заголовок или summary.
+// Если перевести не удалось, пост публикуется на языке оригинала
+func (n *Notifier) localize(ctx context.Context, article model.Article, post render.Post, destination string) render.Post {
+	target := n.translationTarget(post, destination)
+	if target == "" {
+		return post
+	}
+
+	hash := translationHash(post)
+
+	translation, err := n.translations.Translation(ctx, article.ID, target)
+	if err != nil {
+		log.Printf("[ERROR] failed to get translation of article %d to %s: %v", article.ID, target, err)
+		return post
+	}
+
+	if translation == nil || translation.SourceHash != hash {
+		translated, err := n.translator.Translate(ctx, model.TranslationRequest{
+			ArticleID: article.ID,
+			SourceID:  article.SourceID,
+			Title:     post.Title,
+			Summary:   post.Summary,
+			TLDR:      post.TLDR,
+			Bullets:   post.Bullets,
+			Entities:  post.Entities,
+			From:      post.OriginalLanguage,
+			To:        target,
+		})
+		if err != nil {
+			log.Printf("[ERROR] failed to translate article %d to %s: %v", article.ID, target, err)
+			return post
+		}
+
+		translated.ArticleID = article.ID
+		translated.Language = target
+		translated.SourceHash = hash
+
+		if err := n.translations.SaveTranslation(ctx, translated); err != nil {
+			log.Printf("[ERROR] failed to save translation of article %d to %s: %v", article.ID, target, err)
+		}
+
+		translation = &translated
+	}
+
+	return applyTranslation(post, *translation)
+}
+
+// Язык, на который нужно перевести пост для места назначения destination. Пустая строка - переводить не нужно
+func (n *Notifier) translationTarget(post render.Post, destination string) string {
+	target := n.targetLanguages[destination]
+	if target == "" || n.translator == nil || post.OriginalLanguage == "" || post.OriginalLanguage == target {
+		return ""
+	}
+
+	return target
+}
+
+// Хеш переводимого текста поста, по нему проверяется, что сохраненный перевод актуален.
+// Структура входит в хеш, только если она есть, чтобы переводы обычных summary остались действительны
+func translationHash(post render.Post) string {
+	source := post.Title + "\n" + post.Summary
+	if post.TLDR != "" {
+		source += "\n" + post.TLDR + "\n" + strings.Join(post.Bullets, "\n") + "\n" + strings.Join(post.Entities, "\n")
+	}
+
+	return textHash(source)
+}
+
+// Подставляет в пост перевод translation
+func applyTranslation(post render.Post, translation model.Translation) render.Post {
+	post.Title = translation.Title
+	post.Summary = translation.Summary
+	post.TLDR, post.Bullets, post.Entities = translation.TLDR, translation.Bullets, translation.Entities
+	post.Language = translation.Language
+	post.Translated = true
+
+	return post
+}
+
+// Рендерит пост для выбранной статьи, не отправляя его. Используется админами, чтобы проверить шаблоны.
+// Пустой destination означает канал телеграма
+func (n *Notifier) Preview(ctx context.Context, articleID int64, destination string) (string, error) {
+	if destination == "" {
+		destination = publisher.TelegramName
+	}
+
+	article, err := n.articles.ArticleByID(ctx, articleID)
+	if err != nil {
+		return "", err
+	}
+
+	source, err := n.sources.SourceByID(ctx, article.SourceID)
+	if err != nil {
+		return "", err
+	}
+
+	// Превью только показывает пост и ничего не сохраняет: флаг paywall определяется заново, но не записывается,
+	// язык и теги берутся как есть, а summary и перевод - только уже сохраненные, чтобы не тратить бюджет LLM
+	text, paywalled, err := n.articleText(ctx, *article)
+	if err != nil {
+		return "", err
+	}
+	article.Paywalled = paywalled
+
+	saved, err := n.savedSummary(ctx, *article, *source)
+	if err != nil {
+		return "", err
+	}
+
+	post := newPost(*article, *source, text, saved)
+
+	if target := n.translationTarget(post, destination); target != "" {
+		translation, err := n.translations.Translation(ctx, article.ID, target)
+		if err != nil {
+			return "", fmt.Errorf("get translation: %w", err)
+		}
+
+		if translation != nil && translation.SourceHash == translationHash(post) {
+			post = applyTranslation(post, *translation)
+		}
+	}
+
+	return n.renderer.Render(destination, article.SourceID, post)
+}
+
+// Summary, которое уже есть у статьи, без генерации нового. Summary, сгенерированное по устаревшему тексту,
+// тоже подходит: при публикации оно будет сгенерировано заново, но для проверки шаблона этого достаточно
+func (n *Notifier) savedSummary(ctx context.Context, article model.Article, source model.Source) (model.Summary, error) {
+	if article.EditedSummary != "" {
+		return model.Summary{ArticleID: article.ID, Text: article.EditedSummary}, nil
+	}
+
+	settings, err := n.summaries.Settings(ctx, source.ID)
+	if err != nil {
+		return model.Summary{}, fmt.Errorf("get summary settings: %w", err)
+	}
+
+	if !settings.Enabled {
+		return model.Summary{}, nil
+	}
+
+	saved, err := n.summaries.SummaryByArticleID(ctx, article.ID)
+	if err != nil {
+		return model.Summary{}, fmt.Errorf("get saved summary: %w", err)
+	}
+
+	if saved == nil {
+		return model.Summary{}, nil
+	}
 
+	return *saved, nil
 }
 
 // Библиотека readability создаем много пустых строк в тексте очищенном от html тегов
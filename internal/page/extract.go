@@ -0,0 +1,210 @@
+package page
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/andybalholm/cascadia"
+	"github.com/go-shiori/go-readability"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"golang.org/x/net/html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Фразы, которыми сайты заменяют закрытую часть статьи
+var paywallMarkers = []string{
+	"subscribe to continue reading",
+	"subscribe to read",
+	"subscribers only",
+	"this article is for subscribers",
+	"already a subscriber",
+	"create a free account to continue",
+	"оформите подписку",
+	"доступно только подписчикам",
+	"только для подписчиков",
+	"чтобы продолжить чтение",
+	"уже есть подписка",
+}
+
+// Текст длиннее этого с фразой про подписку считаем статьей, а не тизером: фраза могла попасть из подвала сайта
+const maxTeaserLength = 2000
+
+// Разметка schema.org, которой сайты сообщают поисковикам, что статья платная
+var notAccessibleForFree = regexp.MustCompile(`(?i)"isAccessibleForFree"\s*:\s*"?false"?`)
+
+type RuleProvider interface {
+	Rules(ctx context.Context) ([]model.ExtractionRule, error)
+}
+
+// Текст статьи, извлеченный со страницы
+type Content struct {
+	Text string
+	// Почему текст не годится для summary, например статья закрыта paywall. Пустая строка - с текстом все в порядке
+	Problem string
+}
+
+// Извлекает текст статьи со страницы. Для сайтов с правилами текст берется по селектору правила,
+// для остальных его выделяет readability. Заодно проверяет, что страница не закрыта paywall и текста достаточно
+type Extractor struct {
+	rules RuleProvider
+	// Текст короче этого числа символов считаем тизером, а не статьей
+	minLength int
+}
+
+func NewExtractor(rules RuleProvider, minLength int) *Extractor {
+	return &Extractor{rules: rules, minLength: minLength}
+}
+
+func (e *Extractor) Extract(ctx context.Context, p Page) (Content, error) {
+	rules, err := e.rules.Rules(ctx)
+	if err != nil {
+		return Content{}, fmt.Errorf("get extraction rules: %w", err)
+	}
+
+	rule := matchRule(rules, p.URL.Hostname())
+
+	doc, err := html.Parse(strings.NewReader(p.HTML))
+	if err != nil {
+		return Content{}, err
+	}
+
+	paywalled := notAccessibleForFree.MatchString(p.HTML)
+
+	var text string
+	if rule != nil {
+		if rule.PaywallSelector != "" && len(query(doc, rule.PaywallSelector)) > 0 {
+			paywalled = true
+		}
+
+		for _, selector := range rule.StripSelectors {
+			for _, node := range query(doc, selector) {
+				if node.Parent != nil {
+					node.Parent.RemoveChild(node)
+				}
+			}
+		}
+
+		if rule.ContentSelector != "" {
+			text = nodesText(query(doc, rule.ContentSelector))
+		}
+	}
+
+	// Без правила или если селектор правила ничего не нашел, например после редизайна сайта, текст выделяет readability
+	if text == "" {
+		article, err := readability.FromDocument(doc, p.URL)
+		if err != nil {
+			return Content{}, err
+		}
+		text = article.TextContent
+	}
+
+	// Разметка paywall есть и у страниц с лимитом бесплатных статей, где полный текст все равно отдается.
+	// Поэтому она только уточняет причину, если с самим текстом что-то не так
+	problem := e.Check(text)
+	if problem != "" && paywalled {
+		problem = "page is marked as paywalled, " + problem
+	}
+
+	return Content{Text: text, Problem: problem}, nil
+}
+
+// Проверяет, что текст похож на статью, а не на тизер или заглушку paywall. Возвращает причину или пустую строку
+func (e *Extractor) Check(text string) string {
+	length := utf8.RuneCountInString(strings.TrimSpace(text))
+	if length < e.minLength {
+		return fmt.Sprintf("text is too short: %d characters", length)
+	}
+
+	if length <= maxTeaserLength {
+		lower := strings.ToLower(text)
+		for _, marker := range paywallMarkers {
+			if strings.Contains(lower, marker) {
+				return fmt.Sprintf("text looks like a paywall teaser: %q", marker)
+			}
+		}
+	}
+
+	return ""
+}
+
+// Проверяет, что селекторы правила корректны
+func ValidateRule(rule model.ExtractionRule) error {
+	selectors := append([]string{rule.ContentSelector, rule.PaywallSelector}, rule.StripSelectors...)
+	for _, selector := range selectors {
+		if selector == "" {
+			continue
+		}
+
+		if _, err := cascadia.ParseGroup(selector); err != nil {
+			return fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+	}
+
+	return nil
+}
+
+// Нормализует домен правила: нижний регистр и без www
+func NormalizeDomain(domain string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+}
+
+// Правило для хоста или его ближайшего родительского домена. Если подходящего правила нет, возвращает nil
+func matchRule(rules []model.ExtractionRule, host string) *model.ExtractionRule {
+	host = NormalizeDomain(host)
+
+	var matched *model.ExtractionRule
+	for i, rule := range rules {
+		if host != rule.Domain && !strings.HasSuffix(host, "."+rule.Domain) {
+			continue
+		}
+
+		if matched == nil || len(rule.Domain) > len(matched.Domain) {
+			matched = &rules[i]
+		}
+	}
+
+	return matched
+}
+
+// Некорректный селектор ничего не находит. Селекторы проверяются при сохранении правила, см. ValidateRule
+func query(doc *html.Node, selector string) []*html.Node {
+	group, err := cascadia.ParseGroup(selector)
+	if err != nil {
+		return nil
+	}
+
+	return cascadia.QueryAll(doc, group)
+}
+
+// Текст найденных селектором элементов. Элементы внутри уже найденных пропускаются, чтобы текст не повторялся
+func nodesText(nodes []*html.Node) string {
+	selected := make(map[*html.Node]bool, len(nodes))
+	for _, node := range nodes {
+		selected[node] = true
+	}
+
+	var buf bytes.Buffer
+	for _, node := range nodes {
+		if hasSelectedAncestor(node, selected) {
+			continue
+		}
+
+		if err := html.Render(&buf, node); err != nil {
+			return ""
+		}
+	}
+
+	return Text(buf.String())
+}
+
+func hasSelectedAncestor(node *html.Node, selected map[*html.Node]bool) bool {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if selected[parent] {
+			return true
+		}
+	}
+
+	return false
+}
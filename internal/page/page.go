@@ -0,0 +1,201 @@
+package page
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gogs/chardet"
+	"golang.org/x/net/html/charset"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// User-Agent, с которым загружаются страницы статей. Некоторые сайты не отдают страницу клиентам без него
+const userAgent = "Mozilla/5.0 (compatible; news-feed-bot/1.0)"
+
+var (
+	// Страница больше допустимого размера
+	ErrTooLarge = errors.New("page is too large")
+	// Ответ не является html страницей, например это pdf или картинка
+	ErrNotHTML = errors.New("page is not html")
+	// Сайт перенаправляет запрос слишком много раз
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+// Ошибка, когда сайт ответил неуспешным статусом
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return "page responded with " + e.Status
+}
+
+// Загруженная страница статьи
+type Page struct {
+	// Адрес страницы после всех редиректов, относительно него разрешаются относительные ссылки
+	URL *url.URL
+	// HTML страницы, перекодированный в UTF-8
+	HTML string
+}
+
+// Загрузчик страниц статей. Ограничивает время запроса, число редиректов и размер страницы,
+// перекодирует страницу в UTF-8 и повторяет запрос при сетевых ошибках и ошибках сервера
+type Fetcher struct {
+	client *http.Client
+	// Максимальный размер страницы в байтах
+	maxBytes int64
+	// Сколько раз пытаемся загрузить страницу
+	maxAttempts int
+	// Задержка перед повторной попыткой, с каждой попыткой удваивается
+	backoff time.Duration
+}
+
+func NewFetcher(timeout time.Duration, maxRedirects int, maxBytes int64, maxAttempts int, backoff time.Duration) *Fetcher {
+	return &Fetcher{
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				// В via уже сделанные запросы, включая первый, поэтому проходим ровно maxRedirects редиректов
+				if len(via) > maxRedirects {
+					return ErrTooManyRedirects
+				}
+				return nil
+			},
+		},
+		maxBytes:    maxBytes,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+	}
+}
+
+// Загружает страницу по ссылке link
+func (f *Fetcher) Fetch(ctx context.Context, link string) (Page, error) {
+	backoff := f.backoff
+
+	for attempt := 1; ; attempt++ {
+		page, err := f.fetch(ctx, link)
+		if err == nil || attempt >= f.maxAttempts || !retryable(ctx, err) {
+			return page, err
+		}
+
+		log.Printf("[WARN] failed to fetch page %s (attempt %d), retrying in %s: %v", link, attempt, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return Page{}, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+func (f *Fetcher) fetch(ctx context.Context, link string) (Page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return Page{}, err
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Page{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Page{}, &StatusError{Code: resp.StatusCode, Status: resp.Status}
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !isHTML(contentType) {
+		return Page{}, fmt.Errorf("%w: %s", ErrNotHTML, contentType)
+	}
+
+	// Читаем на байт больше лимита, чтобы отличить страницу ровно в лимит от более длинной
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return Page{}, err
+	}
+	if int64(len(body)) > f.maxBytes {
+		return Page{}, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, f.maxBytes)
+	}
+
+	// Если сервер не указал тип, определяем его по содержимому
+	if contentType == "" && !isHTML(http.DetectContentType(body)) {
+		return Page{}, ErrNotHTML
+	}
+
+	html, err := decode(body, contentType)
+	if err != nil {
+		return Page{}, fmt.Errorf("decode page: %w", err)
+	}
+
+	return Page{URL: resp.Request.URL, HTML: html}, nil
+}
+
+// Пустой тип считаем подходящим, его проверяем уже по содержимому страницы
+func isHTML(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// Перекодирует страницу в UTF-8. Кодировка берется из BOM, заголовка Content-Type или тега meta.
+// Если ничего из этого нет, а страница не в UTF-8, кодировку определяет chardet
+func decode(body []byte, contentType string) (string, error) {
+	enc, name, certain := charset.DetermineEncoding(body, contentType)
+
+	// windows-1252 без уверенности - это кодировка по умолчанию, когда страница не в UTF-8 и кодировка нигде не указана.
+	// Часто ее же ошибочно указывают в meta для страниц на кириллице, поэтому доверяем определению по содержимому
+	if !certain && name == "windows-1252" {
+		if result, err := chardet.NewHtmlDetector().DetectBest(body); err == nil {
+			if detected, detectedName := charset.Lookup(result.Charset); detected != nil {
+				enc, name = detected, detectedName
+			}
+		}
+	}
+
+	if name == "utf-8" {
+		return string(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))), nil
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return "", err
+	}
+
+	return string(decoded), nil
+}
+
+// Повторять имеет смысл сетевые ошибки, таймауты и ошибки сервера
+func retryable(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && !Permanent(err)
+}
+
+// Ошибка, которая не изменится при повторе: ответ 4xx, кроме 429, не html страница,
+// слишком большая страница или слишком много редиректов
+func Permanent(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code != http.StatusTooManyRequests && statusErr.Code < 500
+	}
+
+	return errors.Is(err, ErrTooLarge) || errors.Is(err, ErrNotHTML) || errors.Is(err, ErrTooManyRedirects)
+}
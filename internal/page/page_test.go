@@ -0,0 +1,240 @@
+package page
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testHTML = "<html><head><title>Статья</title></head><body><p>Текст статьи</p></body></html>"
+
+func newTestFetcher(maxBytes int64) *Fetcher {
+	return NewFetcher(5*time.Second, 3, maxBytes, 3, time.Millisecond)
+}
+
+// Сервер, который считает запросы и отвечает handler
+func newPageServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, hit int32)) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, atomic.AddInt32(&hits, 1))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &hits
+}
+
+func TestFetchRedirects(t *testing.T) {
+	server, _ := newPageServer(t, func(w http.ResponseWriter, r *http.Request, _ int32) {
+		var step int
+		if _, err := fmt.Sscanf(r.URL.Path, "/redirect/%d", &step); err == nil && step > 0 {
+			http.Redirect(w, r, fmt.Sprintf("/redirect/%d", step-1), http.StatusFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(testHTML))
+	})
+
+	page, err := newTestFetcher(1<<20).Fetch(context.Background(), server.URL+"/redirect/3")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	// Относительные ссылки разрешаются от адреса после редиректов
+	if page.URL.Path != "/redirect/0" {
+		t.Errorf("page url = %s, want the final address", page.URL)
+	}
+	if page.HTML != testHTML {
+		t.Errorf("page html = %q, want %q", page.HTML, testHTML)
+	}
+}
+
+func TestFetchTooManyRedirects(t *testing.T) {
+	server, hits := newPageServer(t, func(w http.ResponseWriter, r *http.Request, hit int32) {
+		http.Redirect(w, r, fmt.Sprintf("/loop/%d", hit), http.StatusFound)
+	})
+
+	_, err := newTestFetcher(1<<20).Fetch(context.Background(), server.URL)
+	if !errors.Is(err, ErrTooManyRedirects) {
+		t.Fatalf("Fetch() error = %v, want ErrTooManyRedirects", err)
+	}
+	if !Permanent(err) {
+		t.Errorf("Permanent(%v) = false, want true", err)
+	}
+	// Исходный запрос и 3 редиректа, без повторных попыток
+	if got := atomic.LoadInt32(hits); got != 4 {
+		t.Errorf("server got %d requests, want 4", got)
+	}
+}
+
+func TestFetchSizeLimit(t *testing.T) {
+	const maxBytes = 100
+
+	tests := []struct {
+		name    string
+		size    int
+		wantErr error
+	}{
+		{name: "exactly the limit", size: maxBytes},
+		{name: "over the limit", size: maxBytes + 1, wantErr: ErrTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := "<html><body>" + strings.Repeat("a", tt.size-len("<html><body>"))
+			server, hits := newPageServer(t, func(w http.ResponseWriter, r *http.Request, _ int32) {
+				w.Header().Set("Content-Type", "text/html")
+				_, _ = w.Write([]byte(body))
+			})
+
+			page, err := newTestFetcher(maxBytes).Fetch(context.Background(), server.URL)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Fetch() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && page.HTML != body {
+				t.Errorf("page html is %d bytes, want %d", len(page.HTML), len(body))
+			}
+			if got := atomic.LoadInt32(hits); got != 1 {
+				t.Errorf("server got %d requests, want 1", got)
+			}
+		})
+	}
+}
+
+func TestFetchCharset(t *testing.T) {
+	russian := "<html><head><title>Новости</title></head><body><p>" +
+		strings.Repeat("Сегодня в городе прошла большая конференция, на которой разработчики рассказали о новой версии языка. ", 5) +
+		"</p></body></html>"
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        string
+	}{
+		{
+			name:        "utf-8 with bom",
+			contentType: "text/html",
+			body:        []byte("\xef\xbb\xbf" + testHTML),
+			want:        testHTML,
+		},
+		{
+			name:        "windows-1252 from header",
+			contentType: "text/html; charset=windows-1252",
+			body:        []byte("<html><body><p>caf\xe9 na\xefve</p></body></html>"),
+			want:        "<html><body><p>café naïve</p></body></html>",
+		},
+		{
+			name:        "windows-1251 from meta",
+			contentType: "text/html",
+			body:        windows1251(strings.Replace(russian, "<head>", `<head><meta charset="windows-1251">`, 1)),
+			want:        strings.Replace(russian, "<head>", `<head><meta charset="windows-1251">`, 1),
+		},
+		{
+			// Кодировка нигде не указана: по умолчанию это windows-1252, но chardet определяет кириллицу
+			name:        "windows-1251 detected by chardet",
+			contentType: "text/html",
+			body:        windows1251(russian),
+			want:        russian,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newPageServer(t, func(w http.ResponseWriter, r *http.Request, _ int32) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write(tt.body)
+			})
+
+			page, err := newTestFetcher(1<<20).Fetch(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if page.HTML != tt.want {
+				t.Errorf("page html = %q, want %q", page.HTML, tt.want)
+			}
+		})
+	}
+}
+
+// Кодирует текст из ASCII и кириллицы без ё в windows-1251
+func windows1251(s string) []byte {
+	result := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r >= 'А' && r <= 'я' {
+			result = append(result, byte(r-'А'+0xC0))
+			continue
+		}
+		result = append(result, byte(r))
+	}
+	return result
+}
+
+func TestFetchRetries(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		wantHits    int32
+		wantErr     bool
+		permanent   bool
+	}{
+		{name: "server error is retried", status: http.StatusServiceUnavailable, contentType: "text/html", wantHits: 2},
+		{name: "rate limit is retried", status: http.StatusTooManyRequests, contentType: "text/html", wantHits: 2},
+		{name: "not found is permanent", status: http.StatusNotFound, contentType: "text/html", wantHits: 1, wantErr: true, permanent: true},
+		{name: "not html is permanent", status: http.StatusOK, contentType: "application/pdf", wantHits: 1, wantErr: true, permanent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Первый ответ с заданным статусом и типом, следующие - успешные
+			server, hits := newPageServer(t, func(w http.ResponseWriter, r *http.Request, hit int32) {
+				if hit == 1 {
+					w.Header().Set("Content-Type", tt.contentType)
+					w.WriteHeader(tt.status)
+					return
+				}
+
+				w.Header().Set("Content-Type", "text/html")
+				_, _ = w.Write([]byte(testHTML))
+			})
+
+			_, err := newTestFetcher(1<<20).Fetch(context.Background(), server.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Fetch() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && Permanent(err) != tt.permanent {
+				t.Errorf("Permanent(%v) = %v, want %v", err, !tt.permanent, tt.permanent)
+			}
+			if got := atomic.LoadInt32(hits); got != tt.wantHits {
+				t.Errorf("server got %d requests, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestFetchGivesUpAfterMaxAttempts(t *testing.T) {
+	server, hits := newPageServer(t, func(w http.ResponseWriter, r *http.Request, _ int32) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := newTestFetcher(1<<20).Fetch(context.Background(), server.URL)
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusBadGateway {
+		t.Fatalf("Fetch() error = %v, want 502 status error", err)
+	}
+	if Permanent(err) {
+		t.Errorf("Permanent(%v) = true, want false", err)
+	}
+	if got := atomic.LoadInt32(hits); got != 3 {
+		t.Errorf("server got %d requests, want 3", got)
+	}
+}
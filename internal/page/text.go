@@ -0,0 +1,53 @@
+package page
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"strings"
+)
+
+// Теги, после которых текст продолжается с новой строки
+var blockTags = map[atom.Atom]bool{
+	atom.Br: true, atom.P: true, atom.Div: true, atom.Li: true, atom.Tr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Section: true, atom.Article: true,
+}
+
+// Текст html фрагмента без тегов, например summary из ленты.
+// В отличие от readability, не ищет в фрагменте основной контент, а оставляет весь текст, кроме скриптов и стилей
+func Text(fragment string) string {
+	var (
+		b         strings.Builder
+		tokenizer = html.NewTokenizer(strings.NewReader(fragment))
+		skip      int
+	)
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(b.String())
+		case html.TextToken:
+			if skip == 0 {
+				b.Write(tokenizer.Text())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			tag := atom.Lookup(name)
+			if tag == atom.Script || tag == atom.Style {
+				skip++
+			}
+			if blockTags[tag] {
+				b.WriteString("\n")
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := atom.Lookup(name)
+			if (tag == atom.Script || tag == atom.Style) && skip > 0 {
+				skip--
+			}
+			if blockTags[tag] {
+				b.WriteString("\n")
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/render"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Лимиты Discord на размер заголовка и описания embed
+const (
+	discordTitleLimit       = 256
+	discordDescriptionLimit = 4096
+)
+
+// Публикация статей в канал Discord через вебхук. Статья отправляется как embed
+type Discord struct {
+	client *http.Client
+	url    string
+}
+
+func NewDiscord(url string) *Discord {
+	return &Discord{
+		client: &http.Client{Timeout: webhookTimeout},
+		url:    url,
+	}
+}
+
+func (d *Discord) Name() string {
+	return "discord"
+}
+
+type discordPayload struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	URL         string         `json:"url"`
+	Description string         `json:"description,omitempty"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+	Footer      *discordFooter `json:"footer,omitempty"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+// Спец символы markdown в Discord
+var discordReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	"*", "\\*",
+	"_", "\\_",
+	"~", "\\~",
+	"`", "\\`",
+	"|", "\\|",
+	">", "\\>",
+)
+
+func (d *Discord) Publish(ctx context.Context, article model.Article, source model.Source, post render.Post) (model.Delivery, error) {
+	var suffix string
+	if hashtags := render.Hashtags(post.Topics()); hashtags != "" {
+		suffix = "\n\n" + discordReplacer.Replace(hashtags)
+	}
+	description := strings.TrimSpace(truncateEscaped(post.Summary, discordDescriptionLimit-markup.Len(suffix), discordReplacer.Replace) + suffix)
+
+	footer := post.SourceName
+	if post.Paywalled {
+		footer += " · " + render.PaywallMarker
+	}
+
+	embed := discordEmbed{
+		// Заголовок embed не поддерживает markdown, поэтому его не экранируем
+		Title:       markup.Truncate(post.Title, discordTitleLimit),
+		URL:         post.Link,
+		Description: description,
+		Footer:      &discordFooter{Text: footer},
+	}
+	if !post.PublishedAt.IsZero() {
+		embed.Timestamp = post.PublishedAt.Format(time.RFC3339)
+	}
+
+	body, err := json.Marshal(discordPayload{Embeds: []discordEmbed{embed}})
+	if err != nil {
+		return model.Delivery{}, err
+	}
+
+	return model.Delivery{}, postJSON(ctx, d.client, d.url, body, nil)
+}
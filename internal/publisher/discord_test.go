@@ -0,0 +1,100 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDiscordPublish(t *testing.T) {
+	server, captured := newWebhookServer(t, http.StatusNoContent, "")
+	post := testPost()
+
+	if _, err := NewDiscord(server.URL).Publish(context.Background(), model.Article{}, model.Source{}, post); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	var payload discordPayload
+	if err := json.Unmarshal(captured.body, &payload); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+
+	if len(payload.Embeds) != 1 {
+		t.Fatalf("payload has %d embeds, want 1", len(payload.Embeds))
+	}
+	embed := payload.Embeds[0]
+
+	// Заголовок embed выводится как есть, а в описании спец символы markdown экранируются
+	if embed.Title != post.Title {
+		t.Errorf("title = %q, want %q", embed.Title, post.Title)
+	}
+	if embed.URL != post.Link {
+		t.Errorf("url = %q, want %q", embed.URL, post.Link)
+	}
+	wantDescription := "Вышел \\*новый\\* релиз & много изменений\n\n#go #releases"
+	if embed.Description != wantDescription {
+		t.Errorf("description = %q, want %q", embed.Description, wantDescription)
+	}
+	if embed.Timestamp != "2023-08-08T12:00:00Z" {
+		t.Errorf("timestamp = %q, want 2023-08-08T12:00:00Z", embed.Timestamp)
+	}
+	if embed.Footer == nil || embed.Footer.Text != post.SourceName {
+		t.Errorf("footer = %+v, want %q", embed.Footer, post.SourceName)
+	}
+}
+
+func TestDiscordPublishLimits(t *testing.T) {
+	server, captured := newWebhookServer(t, http.StatusNoContent, "")
+	post := testPost()
+	post.Title = strings.Repeat("т", discordTitleLimit+10)
+	post.Summary = strings.Repeat("с", discordDescriptionLimit+10)
+
+	if _, err := NewDiscord(server.URL).Publish(context.Background(), model.Article{}, model.Source{}, post); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	var payload discordPayload
+	if err := json.Unmarshal(captured.body, &payload); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+
+	embed := payload.Embeds[0]
+	if n := len([]rune(embed.Title)); n != discordTitleLimit {
+		t.Errorf("title is %d characters, want %d", n, discordTitleLimit)
+	}
+	if n := len([]rune(embed.Description)); n != discordDescriptionLimit {
+		t.Errorf("description is %d characters, want %d", n, discordDescriptionLimit)
+	}
+}
+
+func TestDiscordPublishStatus(t *testing.T) {
+	server, _ := newWebhookServer(t, http.StatusBadRequest, `{"message": "Invalid Form Body"}`)
+
+	_, err := NewDiscord(server.URL).Publish(context.Background(), model.Article{}, model.Source{}, testPost())
+	if err == nil || !strings.Contains(err.Error(), "Invalid Form Body") {
+		t.Fatalf("Publish() error = %v, want error with discord response", err)
+	}
+}
+
+func TestDiscordPublishPaywalled(t *testing.T) {
+	server, captured := newWebhookServer(t, http.StatusNoContent, "")
+	post := testPost()
+	post.Paywalled = true
+
+	if _, err := NewDiscord(server.URL).Publish(context.Background(), model.Article{}, model.Source{}, post); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	var payload discordPayload
+	if err := json.Unmarshal(captured.body, &payload); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+
+	wantFooter := "Go Blog · 🔒 по подписке"
+	if footer := payload.Embeds[0].Footer; footer == nil || footer.Text != wantFooter {
+		t.Errorf("footer = %+v, want %q", footer, wantFooter)
+	}
+}
@@ -0,0 +1,208 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Сколько ждем SMTP сервер, если в контексте нет своего дедлайна
+const smtpTimeout = 30 * time.Second
+
+// Отправка писем через SMTP. Используется для email дайджеста.
+// Письмо собирается как multipart/alternative с HTML и текстовой версией
+type Email struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	// Требовать STARTTLS. Если сервер его не поддерживает, письмо не отправляется
+	startTLS  bool
+	tlsConfig *tls.Config
+}
+
+func NewEmail(host string, port int, username string, password string, from string, startTLS bool) *Email {
+	return &Email{
+		host:      host,
+		port:      port,
+		username:  username,
+		password:  password,
+		from:      from,
+		startTLS:  startTLS,
+		tlsConfig: &tls.Config{ServerName: host},
+	}
+}
+
+// Отправляет письмо каждому получателю отдельно, чтобы получатели не видели адреса друг друга.
+// Ошибка одного получателя, например несуществующий адрес, не мешает отправке остальным.
+// Возвращает, скольким получателям письмо доставлено, и ошибки по остальным
+func (e *Email) Send(ctx context.Context, recipients []string, subject string, html string, text string) (int, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(e.host, strconv.Itoa(e.port)))
+	if err != nil {
+		return 0, err
+	}
+	// net/smtp не умеет работать с контекстом, поэтому ограничиваем всю сессию дедлайном соединения
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return 0, err
+	}
+
+	client, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		conn.Close()
+		return 0, err
+	}
+	defer client.Close()
+
+	if e.startTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return 0, fmt.Errorf("smtp server %s does not support STARTTLS", e.host)
+		}
+
+		if err := client.StartTLS(e.tlsConfig); err != nil {
+			return 0, fmt.Errorf("starttls: %w", err)
+		}
+	}
+
+	if e.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.username, e.password, e.host)); err != nil {
+			return 0, fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	var (
+		sent     int
+		failures []string
+	)
+
+	for i, recipient := range recipients {
+		message, err := e.message(recipient, subject, html, text)
+		if err != nil {
+			return sent, err
+		}
+
+		if err := e.send(client, recipient, message); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", recipient, err))
+
+			// Сбрасываем незавершенную транзакцию, иначе сервер отклонит и следующего получателя.
+			// Если не удалось и это, соединение потеряно, и остальным отправить уже не получится
+			if err := client.Reset(); err != nil {
+				for _, rest := range recipients[i+1:] {
+					failures = append(failures, fmt.Sprintf("%s: not sent: %v", rest, err))
+				}
+				break
+			}
+			continue
+		}
+
+		sent++
+	}
+
+	if len(failures) > 0 {
+		return sent, fmt.Errorf("send email to %d of %d recipients failed: %s", len(failures), len(recipients), strings.Join(failures, "; "))
+	}
+
+	return sent, client.Quit()
+}
+
+func (e *Email) send(client *smtp.Client, recipient string, message []byte) error {
+	if err := client.Mail(e.from); err != nil {
+		return err
+	}
+
+	if err := client.Rcpt(recipient); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(message); err != nil {
+		w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+// Собирает письмо multipart/alternative. Текстовая версия идет первой, почтовые клиенты показывают последнюю поддерживаемую
+func (e *Email) message(recipient string, subject string, html string, text string) ([]byte, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{contentType: "text/plain; charset=utf-8", content: text},
+		{contentType: "text/html; charset=utf-8", content: html},
+	}
+
+	for _, part := range parts {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	headers := [][2]string{
+		{"From", e.from},
+		{"To", recipient},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", messageID(e.host)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + w.Boundary()},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&message, "%s: %s\r\n", header[0], header[1])
+	}
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+
+	return message.Bytes(), nil
+}
+
+func messageID(host string) string {
+	b := make([]byte, 16)
+	// Если случайные байты не получить, Message-ID останется уникальным за счет времени
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), host)
+}
@@ -0,0 +1,348 @@
+package publisher
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Письмо, принятое фейковым SMTP сервером
+type envelope struct {
+	from string
+	to   []string
+	data string
+	tls  bool
+}
+
+// Минимальный SMTP сервер для тестов: поддерживает STARTTLS, отклоняет получателей из rejected
+// и запоминает принятые письма
+type fakeSMTP struct {
+	listener net.Listener
+	cert     *x509.Certificate
+	tls      *tls.Config
+	startTLS bool
+	rejected map[string]bool
+
+	mu        sync.Mutex
+	envelopes []envelope
+}
+
+func newFakeSMTP(t *testing.T, startTLS bool, rejected ...string) *fakeSMTP {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	cert, tlsCert := selfSignedCert(t)
+
+	s := &fakeSMTP{
+		listener: listener,
+		cert:     cert,
+		tls:      &tls.Config{Certificates: []tls.Certificate{tlsCert}},
+		startTLS: startTLS,
+		rejected: make(map[string]bool),
+	}
+	for _, recipient := range rejected {
+		s.rejected[recipient] = true
+	}
+
+	go s.serve()
+	return s
+}
+
+// Клиент, который доверяет сертификату фейкового сервера
+func (s *fakeSMTP) email(startTLS bool) *Email {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	e := NewEmail("127.0.0.1", addr.Port, "", "", "bot@example.com", startTLS)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(s.cert)
+	e.tlsConfig.RootCAs = pool
+
+	return e
+}
+
+func (s *fakeSMTP) received() []envelope {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]envelope(nil), s.envelopes...)
+}
+
+func (s *fakeSMTP) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTP) handle(conn net.Conn) {
+	defer func() { conn.Close() }()
+
+	text := textproto.NewConn(conn)
+	secure := false
+	var current envelope
+
+	_ = text.PrintfLine("220 localhost ESMTP")
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			if s.startTLS && !secure {
+				_ = text.PrintfLine("250-localhost\r\n250 STARTTLS")
+			} else {
+				_ = text.PrintfLine("250 localhost")
+			}
+		case "STARTTLS":
+			_ = text.PrintfLine("220 ready to start TLS")
+
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, text, secure = tlsConn, textproto.NewConn(tlsConn), true
+		case "MAIL":
+			current = envelope{from: addressArg(arg), tls: secure}
+			_ = text.PrintfLine("250 ok")
+		case "RCPT":
+			recipient := addressArg(arg)
+			if s.rejected[recipient] {
+				_ = text.PrintfLine("550 no such user")
+				continue
+			}
+			current.to = append(current.to, recipient)
+			_ = text.PrintfLine("250 ok")
+		case "DATA":
+			_ = text.PrintfLine("354 go ahead")
+
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			current.data = string(data)
+
+			s.mu.Lock()
+			s.envelopes = append(s.envelopes, current)
+			s.mu.Unlock()
+
+			current = envelope{}
+			_ = text.PrintfLine("250 ok")
+		case "RSET":
+			current = envelope{}
+			_ = text.PrintfLine("250 ok")
+		case "NOOP":
+			_ = text.PrintfLine("250 ok")
+		case "QUIT":
+			_ = text.PrintfLine("221 bye")
+			return
+		default:
+			_ = text.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+// Адрес из аргумента команды вида FROM:<addr> или TO:<addr>
+func addressArg(arg string) string {
+	_, addr, _ := strings.Cut(arg, "<")
+	addr, _, _ = strings.Cut(addr, ">")
+	return addr
+}
+
+func selfSignedCert(t *testing.T) (*x509.Certificate, tls.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestEmailSendStartTLS(t *testing.T) {
+	server := newFakeSMTP(t, true)
+	recipients := []string{"alice@example.com", "bob@example.com"}
+
+	sent, err := server.email(true).Send(context.Background(), recipients, "Дайджест", "<p>Привет</p>", "Привет")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if sent != len(recipients) {
+		t.Fatalf("Send() sent = %d, want %d", sent, len(recipients))
+	}
+
+	envelopes := server.received()
+	if len(envelopes) != len(recipients) {
+		t.Fatalf("server received %d envelopes, want %d", len(envelopes), len(recipients))
+	}
+
+	for i, env := range envelopes {
+		// Каждому получателю свой конверт, чтобы получатели не видели адреса друг друга
+		if len(env.to) != 1 || env.to[0] != recipients[i] {
+			t.Errorf("envelope %d recipients = %v, want [%s]", i, env.to, recipients[i])
+		}
+		if env.from != "bot@example.com" {
+			t.Errorf("envelope %d from = %q", i, env.from)
+		}
+		if !env.tls {
+			t.Errorf("envelope %d was sent without STARTTLS", i)
+		}
+
+		msg, err := mail.ReadMessage(strings.NewReader(env.data))
+		if err != nil {
+			t.Fatalf("envelope %d: read message: %v", i, err)
+		}
+
+		if to := msg.Header.Get("To"); to != recipients[i] {
+			t.Errorf("envelope %d To = %q, want %q", i, to, recipients[i])
+		}
+
+		subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+		if err != nil || subject != "Дайджест" {
+			t.Errorf("envelope %d Subject = %q (%v), want %q", i, subject, err, "Дайджест")
+		}
+
+		mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/alternative" {
+			t.Fatalf("envelope %d Content-Type = %q (%v), want multipart/alternative", i, mediaType, err)
+		}
+
+		parts := readParts(t, multipart.NewReader(msg.Body, params["boundary"]))
+		want := [][2]string{
+			{"text/plain", "Привет"},
+			{"text/html", "<p>Привет</p>"},
+		}
+		if len(parts) != len(want) {
+			t.Fatalf("envelope %d has %d parts, want %d", i, len(parts), len(want))
+		}
+		for j := range want {
+			if parts[j] != want[j] {
+				t.Errorf("envelope %d part %d = %q, want %q", i, j, parts[j], want[j])
+			}
+		}
+	}
+}
+
+// Тип и раскодированное содержимое частей письма
+func readParts(t *testing.T, r *multipart.Reader) [][2]string {
+	t.Helper()
+
+	var parts [][2]string
+	for {
+		part, err := r.NextRawPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mediaType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Части читаются как есть, чтобы проверить, что текст закодирован в quoted-printable
+		if encoding := part.Header.Get("Content-Transfer-Encoding"); encoding != "quoted-printable" {
+			t.Errorf("part %s Content-Transfer-Encoding = %q, want quoted-printable", mediaType, encoding)
+		}
+
+		content, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parts = append(parts, [2]string{mediaType, string(content)})
+	}
+}
+
+func TestEmailSendContinuesAfterRejectedRecipient(t *testing.T) {
+	server := newFakeSMTP(t, false, "missing@example.com")
+	recipients := []string{"alice@example.com", "missing@example.com", "bob@example.com"}
+
+	sent, err := server.email(false).Send(context.Background(), recipients, "Дайджест", "<p>Привет</p>", "Привет")
+	if err == nil || !strings.Contains(err.Error(), "missing@example.com") {
+		t.Fatalf("Send() error = %v, want error about missing@example.com", err)
+	}
+	if sent != 2 {
+		t.Fatalf("Send() sent = %d, want 2", sent)
+	}
+
+	envelopes := server.received()
+	if len(envelopes) != 2 {
+		t.Fatalf("server received %d envelopes, want 2", len(envelopes))
+	}
+	if envelopes[0].to[0] != "alice@example.com" || envelopes[1].to[0] != "bob@example.com" {
+		t.Errorf("envelopes were sent to %v and %v", envelopes[0].to, envelopes[1].to)
+	}
+	for i, env := range envelopes {
+		if len(env.to) != 1 {
+			t.Errorf("envelope %d recipients = %v, want exactly one", i, env.to)
+		}
+	}
+}
+
+func TestEmailSendRequiresStartTLS(t *testing.T) {
+	server := newFakeSMTP(t, false)
+
+	sent, err := server.email(true).Send(context.Background(), []string{"alice@example.com"}, "Дайджест", "", "Привет")
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("Send() error = %v, want STARTTLS error", err)
+	}
+	if sent != 0 {
+		t.Errorf("Send() sent = %d, want 0", sent)
+	}
+	if envelopes := server.received(); len(envelopes) != 0 {
+		t.Errorf("server received %d envelopes without STARTTLS", len(envelopes))
+	}
+}
@@ -0,0 +1,86 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/render"
+	"net/http"
+	"strings"
+)
+
+// Лимит Slack на текст section блока
+const slackSectionLimit = 3000
+
+// Публикация статей в Slack через incoming webhook. Статья отправляется блоками с разметкой mrkdwn
+type Slack struct {
+	client *http.Client
+	url    string
+}
+
+func NewSlack(url string) *Slack {
+	return &Slack{
+		client: &http.Client{Timeout: webhookTimeout},
+		url:    url,
+	}
+}
+
+func (s *Slack) Name() string {
+	return "slack"
+}
+
+type slackPayload struct {
+	// Текст для уведомлений, где блоки не отображаются
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// В mrkdwn Slack нужно экранировать только эти символы
+var slackReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func (s *Slack) Publish(ctx context.Context, article model.Article, source model.Source, post render.Post) (model.Delivery, error) {
+	text := fmt.Sprintf("*<%s|%s>*", post.Link, slackReplacer.Replace(post.Title))
+	if post.Summary != "" {
+		text += "\n\n" + truncateEscaped(post.Summary, slackSectionLimit-markup.Len(text)-2, slackReplacer.Replace)
+	}
+
+	footer := post.SourceName
+	if post.Paywalled {
+		footer += " · " + render.PaywallMarker
+	}
+	if hashtags := render.Hashtags(post.Topics()); hashtags != "" {
+		footer += " · " + hashtags
+	}
+
+	body, err := json.Marshal(slackPayload{
+		Text: post.Title,
+		Blocks: []slackBlock{
+			{
+				Type: "section",
+				Text: &slackText{Type: "mrkdwn", Text: text},
+			},
+			{
+				Type:     "context",
+				Elements: []slackText{{Type: "mrkdwn", Text: slackReplacer.Replace(footer)}},
+			},
+		},
+	})
+	if err != nil {
+		return model.Delivery{}, err
+	}
+
+	return model.Delivery{}, postJSON(ctx, s.client, s.url, body, nil)
+}
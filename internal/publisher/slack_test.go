@@ -0,0 +1,117 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSlackPublish(t *testing.T) {
+	server, captured := newWebhookServer(t, http.StatusOK, "ok")
+	post := testPost()
+
+	if _, err := NewSlack(server.URL).Publish(context.Background(), model.Article{}, model.Source{}, post); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	var payload slackPayload
+	if err := json.Unmarshal(captured.body, &payload); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+
+	if payload.Text != post.Title {
+		t.Errorf("text = %q, want %q", payload.Text, post.Title)
+	}
+	if len(payload.Blocks) != 2 {
+		t.Fatalf("payload has %d blocks, want 2", len(payload.Blocks))
+	}
+
+	// В mrkdwn экранируются только &, < и >, а ссылка записывается как <url|текст>
+	section := payload.Blocks[0]
+	wantSection := "*<https://example.com/go|Go 1.21 &lt;released&gt;>*\n\nВышел *новый* релиз &amp; много изменений"
+	if section.Type != "section" || section.Text == nil || section.Text.Type != "mrkdwn" || section.Text.Text != wantSection {
+		t.Errorf("section block = %+v, want mrkdwn %q", section, wantSection)
+	}
+
+	footer := payload.Blocks[1]
+	if footer.Type != "context" || len(footer.Elements) != 1 || footer.Elements[0].Text != "Go Blog · #go #releases" {
+		t.Errorf("context block = %+v, want footer with source and hashtags", footer)
+	}
+}
+
+func TestSlackPublishLimit(t *testing.T) {
+	server, captured := newWebhookServer(t, http.StatusOK, "ok")
+	post := testPost()
+	post.Summary = strings.Repeat("с", slackSectionLimit+10)
+
+	if _, err := NewSlack(server.URL).Publish(context.Background(), model.Article{}, model.Source{}, post); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	var payload slackPayload
+	if err := json.Unmarshal(captured.body, &payload); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+
+	if n := len([]rune(payload.Blocks[0].Text.Text)); n != slackSectionLimit {
+		t.Errorf("section is %d characters, want %d", n, slackSectionLimit)
+	}
+}
+
+func TestSlackPublishStatus(t *testing.T) {
+	// Slack отвечает на ошибки текстом вроде invalid_blocks или channel_not_found
+	server, _ := newWebhookServer(t, http.StatusNotFound, "channel_not_found")
+
+	_, err := NewSlack(server.URL).Publish(context.Background(), model.Article{}, model.Source{}, testPost())
+	if err == nil || !strings.Contains(err.Error(), "channel_not_found") {
+		t.Fatalf("Publish() error = %v, want error with slack response", err)
+	}
+}
+
+func TestSlackPublishPaywalled(t *testing.T) {
+	server, captured := newWebhookServer(t, http.StatusOK, "ok")
+	post := testPost()
+	post.Paywalled = true
+
+	if _, err := NewSlack(server.URL).Publish(context.Background(), model.Article{}, model.Source{}, post); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	var payload slackPayload
+	if err := json.Unmarshal(captured.body, &payload); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+
+	wantFooter := "Go Blog · 🔒 по подписке · #go #releases"
+	if footer := payload.Blocks[1].Elements[0].Text; footer != wantFooter {
+		t.Errorf("footer = %q, want %q", footer, wantFooter)
+	}
+}
+
+func TestSlackPublishLimitEscaped(t *testing.T) {
+	server, captured := newWebhookServer(t, http.StatusOK, "ok")
+	post := testPost()
+	post.Summary = strings.Repeat("&", slackSectionLimit)
+
+	if _, err := NewSlack(server.URL).Publish(context.Background(), model.Article{}, model.Source{}, post); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	var payload slackPayload
+	if err := json.Unmarshal(captured.body, &payload); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+
+	// Текст обрезается до экранирования, поэтому &amp; не разрезается и лимит соблюдается с учетом экранирования
+	section := payload.Blocks[0].Text.Text
+	if n := len([]rune(section)); n > slackSectionLimit {
+		t.Errorf("section is %d characters, want at most %d", n, slackSectionLimit)
+	}
+	summary := strings.TrimSuffix(section[strings.LastIndex(section, "\n")+1:], "…")
+	if strings.ReplaceAll(summary, "&amp;", "") != "" {
+		t.Errorf("summary %q contains a cut escape sequence", summary)
+	}
+}
@@ -0,0 +1,186 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/render"
+	"strings"
+)
+
+// Имя места назначения для канала телеграма, по нему выбирается шаблон destination_telegram.tmpl
+const TelegramName = "telegram"
+
+type ReactionCounter interface {
+	Counts(ctx context.Context, articleID int64) (map[string]int, error)
+}
+
+// Публикация статей в канал телеграма.
+// Текст поста рендерится по шаблонам, под постом выводится inline клавиатура
+type Telegram struct {
+	bot *tgbotapi.BotAPI
+	// id канала куда мы будем постить статьи
+	channelID int64
+	// Чат редакторов, куда статьи отправляются на модерацию
+	moderationChatID int64
+	// Шаблоны постов
+	renderer *render.Renderer
+	// Настройки inline клавиатуры под постами
+	keyboard render.Keyboard
+	// Счетчики реакций, чтобы не сбросить их при редактировании поста
+	reactions ReactionCounter
+}
+
+func NewTelegram(
+	bot *tgbotapi.BotAPI,
+	channelID int64,
+	moderationChatID int64,
+	renderer *render.Renderer,
+	keyboard render.Keyboard,
+	reactions ReactionCounter,
+) *Telegram {
+	return &Telegram{
+		bot:              bot,
+		channelID:        channelID,
+		moderationChatID: moderationChatID,
+		renderer:         renderer,
+		keyboard:         keyboard,
+		reactions:        reactions,
+	}
+}
+
+func (t *Telegram) Name() string {
+	return TelegramName
+}
+
+// Отправляет пост в канал и возвращает доставку с id отправленного сообщения
+func (t *Telegram) Publish(ctx context.Context, article model.Article, source model.Source, post render.Post) (model.Delivery, error) {
+	text, err := t.render(article, post, markup.MaxMessageLen)
+	if err != nil {
+		return model.Delivery{}, err
+	}
+
+	msg := tgbotapi.NewMessage(t.channelID, text)
+	// Даем понять телеграм, в какой разметке отрендерен пост
+	msg.ParseMode = t.renderer.Mode().String()
+
+	if keyboard := t.keyboard.Build(article, source, nil); keyboard != nil {
+		msg.ReplyMarkup = keyboard
+	}
+
+	sent, err := t.bot.Send(msg)
+	if err != nil {
+		return model.Delivery{}, err
+	}
+
+	return model.Delivery{
+		ChatID:    t.channelID,
+		MessageID: sent.MessageID,
+	}, nil
+}
+
+// Редактирует уже отправленный пост, сохраняя счетчики реакций
+func (t *Telegram) Edit(ctx context.Context, delivery model.Delivery, article model.Article, source model.Source, post render.Post) error {
+	text, err := t.render(article, post, markup.MaxMessageLen)
+	if err != nil {
+		return err
+	}
+
+	counts, err := t.reactions.Counts(ctx, article.ID)
+	if err != nil {
+		return err
+	}
+
+	edit := tgbotapi.NewEditMessageText(delivery.ChatID, delivery.MessageID, text)
+	edit.ParseMode = t.renderer.Mode().String()
+	edit.ReplyMarkup = t.keyboard.Build(article, source, counts)
+
+	if _, err := t.bot.Request(edit); err != nil {
+		// Если текст поста в итоге не поменялся, телеграм возвращает ошибку, это не страшно
+		if strings.Contains(err.Error(), "message is not modified") {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Отправляет пост в чат редакторов с кнопками модерации и возвращает id отправленного сообщения.
+// Редакторы видят пост в том виде, в котором он уйдет в канал
+func (t *Telegram) SendForModeration(ctx context.Context, article model.Article, source model.Source, post render.Post) (int, error) {
+	text, err := t.render(article, post, markup.MaxMessageLen)
+	if err != nil {
+		return 0, err
+	}
+
+	msg := tgbotapi.NewMessage(t.moderationChatID, text)
+	msg.ParseMode = t.renderer.Mode().String()
+	msg.ReplyMarkup = render.ModerationKeyboard(article.ID)
+
+	sent, err := t.bot.Send(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	return sent.MessageID, nil
+}
+
+// Рендерит пост и укорачивает summary, пока пост не влезет в limit символов телеграма.
+// Длина считается по отрендеренному тексту вместе с разметкой, поэтому с запасом: телеграм разметку не считает
+func (t *Telegram) render(article model.Article, post render.Post, limit int) (string, error) {
+	for {
+		text, err := t.renderer.Render(TelegramName, article.SourceID, post)
+		if err != nil {
+			return "", err
+		}
+
+		over := markup.Len(text) - limit
+		if over <= 0 {
+			return text, nil
+		}
+
+		if !shorten(&post, over) {
+			return "", fmt.Errorf("post of article %d is %d characters longer than telegram allows even without summary", article.ID, over)
+		}
+	}
+}
+
+// Укорачивает summary поста примерно на over символов. Сначала убираются упомянутые сущности и последние факты,
+// затем обрезается самый длинный текст. Возвращает false, если укорачивать больше нечего
+func shorten(post *render.Post, over int) bool {
+	if len(post.Entities) > 0 {
+		post.Entities = nil
+		return true
+	}
+
+	if len(post.Bullets) > 1 {
+		post.Bullets = post.Bullets[:len(post.Bullets)-1]
+		return true
+	}
+
+	// Обрезаем самое длинное поле: в шаблоне может выводиться только часть из них, например Summary не выводится при наличии фактов
+	bullets := append([]string(nil), post.Bullets...)
+	fields := []*string{&post.Summary, &post.TLDR}
+	for i := range bullets {
+		fields = append(fields, &bullets[i])
+	}
+
+	longest := fields[0]
+	for _, field := range fields[1:] {
+		if markup.Len(*field) > markup.Len(*longest) {
+			longest = field
+		}
+	}
+
+	if *longest == "" {
+		return false
+	}
+
+	// Слайс фактов общий с постом вызывающего, поэтому меняем копию
+	*longest = markup.Truncate(*longest, markup.Len(*longest)-over)
+	post.Bullets = bullets
+	return true
+}
@@ -0,0 +1,136 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/render"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Заголовок с подписью тела запроса generic вебхука
+const SignatureHeader = "X-Signature-256"
+
+// Сколько ждем ответа от вебхука
+const webhookTimeout = 10 * time.Second
+
+// Публикация статей в произвольный вебхук в виде JSON.
+// Если задан секрет, тело запроса подписывается HMAC-SHA256, чтобы получатель мог проверить, что запрос пришел от нас
+type Webhook struct {
+	client *http.Client
+	url    string
+	secret string
+}
+
+func NewWebhook(url string, secret string) *Webhook {
+	return &Webhook{
+		client: &http.Client{Timeout: webhookTimeout},
+		url:    url,
+		secret: secret,
+	}
+}
+
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+// Тело запроса generic вебхука
+type webhookPayload struct {
+	ID          int64     `json:"id"`
+	Title       string    `json:"title"`
+	Link        string    `json:"link"`
+	Summary     string    `json:"summary"`
+	TLDR        string    `json:"tldr,omitempty"`
+	Bullets     []string  `json:"bullets,omitempty"`
+	Entities    []string  `json:"entities,omitempty"`
+	Paywalled   bool      `json:"paywalled,omitempty"`
+	Source      string    `json:"source"`
+	Categories  []string  `json:"categories"`
+	Tags        []string  `json:"tags,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+	ReadingTime int       `json:"reading_time"`
+}
+
+func (w *Webhook) Publish(ctx context.Context, article model.Article, source model.Source, post render.Post) (model.Delivery, error) {
+	body, err := json.Marshal(webhookPayload{
+		ID:          post.ID,
+		Title:       post.Title,
+		Link:        post.Link,
+		Summary:     post.Summary,
+		TLDR:        post.TLDR,
+		Bullets:     post.Bullets,
+		Entities:    post.Entities,
+		Paywalled:   post.Paywalled,
+		Source:      post.SourceName,
+		Categories:  post.Categories,
+		Tags:        post.Tags,
+		PublishedAt: post.PublishedAt,
+		ReadingTime: post.ReadingTime,
+	})
+	if err != nil {
+		return model.Delivery{}, err
+	}
+
+	headers := map[string]string{}
+	if w.secret != "" {
+		headers[SignatureHeader] = "sha256=" + Sign(w.secret, body)
+	}
+
+	return model.Delivery{}, postJSON(ctx, w.client, w.url, body, headers)
+}
+
+// Подпись тела запроса HMAC-SHA256 в hex
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Отправляет JSON на url и проверяет, что вебхук ответил успешным статусом
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Тело ответа обрезаем, чтобы в ошибку не попала огромная html страница
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook responded with %s: %s", resp.Status, respBody)
+	}
+
+	return nil
+}
+
+// Обрезает обычный текст так, чтобы после экранирования он занимал не больше max символов.
+// Текст обрезается до экранирования, иначе обрезка может разрезать последовательность вроде &amp;
+func truncateEscaped(s string, max int, escape func(string) string) string {
+	limit := max
+	for {
+		escaped := escape(markup.Truncate(s, limit))
+		over := markup.Len(escaped) - max
+		if over <= 0 || limit <= 0 {
+			return escaped
+		}
+		limit -= over
+	}
+}
@@ -0,0 +1,160 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/render"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Запрос, который получил тестовый вебхук
+type capturedRequest struct {
+	method string
+	header http.Header
+	body   []byte
+}
+
+// Тестовый вебхук, который отвечает статусом status и запоминает последний запрос
+func newWebhookServer(t *testing.T, status int, response string) (*httptest.Server, *capturedRequest) {
+	t.Helper()
+
+	captured := &capturedRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("read request body: %v", err)
+		}
+
+		captured.method, captured.header, captured.body = r.Method, r.Header.Clone(), body
+
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, captured
+}
+
+func testPost() render.Post {
+	return render.Post{
+		ID:          42,
+		Title:       "Go 1.21 <released>",
+		Link:        "https://example.com/go",
+		Summary:     "Вышел *новый* релиз & много изменений",
+		TLDR:        "Новый релиз",
+		Bullets:     []string{"Дженерики быстрее", "Новый пакет slog"},
+		Entities:    []string{"Go"},
+		SourceName:  "Go Blog",
+		Categories:  []string{"Go", "Releases"},
+		PublishedAt: time.Date(2023, 8, 8, 12, 0, 0, 0, time.UTC),
+		ReadingTime: 3,
+	}
+}
+
+func TestWebhookPublish(t *testing.T) {
+	server, captured := newWebhookServer(t, http.StatusOK, "")
+	post := testPost()
+
+	if _, err := NewWebhook(server.URL, "").Publish(context.Background(), model.Article{}, model.Source{}, post); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	if captured.method != http.MethodPost {
+		t.Errorf("method = %s, want POST", captured.method)
+	}
+	if contentType := captured.header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", contentType)
+	}
+	if signature := captured.header.Get(SignatureHeader); signature != "" {
+		t.Errorf("%s = %q without secret, want none", SignatureHeader, signature)
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(captured.body, &payload); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+
+	want := webhookPayload{
+		ID:          post.ID,
+		Title:       post.Title,
+		Link:        post.Link,
+		Summary:     post.Summary,
+		TLDR:        post.TLDR,
+		Bullets:     post.Bullets,
+		Entities:    post.Entities,
+		Source:      post.SourceName,
+		Categories:  post.Categories,
+		PublishedAt: post.PublishedAt,
+		ReadingTime: post.ReadingTime,
+	}
+	gotJSON, _ := json.Marshal(payload)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("payload = %s, want %s", gotJSON, wantJSON)
+	}
+}
+
+func TestWebhookPublishSignature(t *testing.T) {
+	server, captured := newWebhookServer(t, http.StatusNoContent, "")
+
+	if _, err := NewWebhook(server.URL, "secret").Publish(context.Background(), model.Article{}, model.Source{}, testPost()); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	// Получатель проверяет подпись по сырому телу запроса, поэтому и мы считаем ее по полученным байтам
+	want := "sha256=" + Sign("secret", captured.body)
+	if got := captured.header.Get(SignatureHeader); got != want {
+		t.Errorf("%s = %q, want %q", SignatureHeader, got, want)
+	}
+}
+
+// Неуспешный статус - ошибка публикации, по ней доставка помечается failed и повторяется.
+// Успешный статус без ошибки - доставка delivered
+func TestWebhookPublishStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "no content", status: http.StatusNoContent},
+		{name: "not modified", status: http.StatusNotModified, wantErr: true},
+		{name: "bad request", status: http.StatusBadRequest, wantErr: true},
+		{name: "rate limited", status: http.StatusTooManyRequests, wantErr: true},
+		{name: "server error", status: http.StatusBadGateway, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newWebhookServer(t, tt.status, "invalid payload")
+
+			_, err := NewWebhook(server.URL, "").Publish(context.Background(), model.Article{}, model.Source{}, testPost())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && !strings.Contains(err.Error(), http.StatusText(tt.status)) {
+				t.Errorf("Publish() error = %q, want status %q in it", err, http.StatusText(tt.status))
+			}
+		})
+	}
+}
+
+func TestPostJSONTruncatesResponse(t *testing.T) {
+	server, _ := newWebhookServer(t, http.StatusInternalServerError, strings.Repeat("x", 2048))
+
+	err := postJSON(context.Background(), http.DefaultClient, server.URL, []byte("{}"), nil)
+	if err == nil {
+		t.Fatal("postJSON() error = nil, want error")
+	}
+
+	if len(err.Error()) > 600 {
+		t.Errorf("postJSON() error is %d bytes long, want response body truncated", len(err.Error()))
+	}
+}
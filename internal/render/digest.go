@@ -0,0 +1,126 @@
+package render
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+)
+
+const (
+	// Имена файлов шаблонов дайджеста в каталоге с шаблонами
+	digestHTMLName = "digest_html"
+	digestTextName = "digest_text"
+)
+
+// HTML версия дайджеста по умолчанию: те же заголовок, summary, источник и хэштеги, что и в постах телеграма
+const DefaultDigestHTMLTemplate = `<html>
+<body>
+<h2>{{ .Title }}</h2>
+{{ range .Posts }}
+<h3><a href="{{ .Link }}">{{ .Title }}</a></h3>
+{{ if .Summary }}<p>{{ truncate 500 .Summary }}</p>{{ end }}
+<p><small>{{ .SourceName }} · {{ date "02.01.2006" .PublishedAt }}{{ if .Categories }} · {{ hashtags .Categories }}{{ end }}</small></p>
+{{ end }}
+</body>
+</html>`
+
+// Текстовая версия дайджеста по умолчанию для почтовых клиентов без HTML
+const DefaultDigestTextTemplate = `{{ .Title }}
+{{ range .Posts }}
+{{ .Title }}
+{{ if .Summary }}{{ truncate 500 .Summary }}
+{{ end }}{{ .SourceName }} · {{ date "02.01.2006" .PublishedAt }}
+{{ .Link }}
+{{ end }}`
+
+// Данные дайджеста, которые доступны в шаблонах
+type Digest struct {
+	Title string
+	// Промежуток, за который собраны статьи
+	From  time.Time
+	To    time.Time
+	Posts []Post
+}
+
+// Шаблоны email дайджеста: HTML и текстовая версия письма
+type DigestRenderer struct {
+	html *htmltemplate.Template
+	text *template.Template
+}
+
+// Загружает шаблоны дайджеста digest_html.tmpl и digest_text.tmpl из каталога dir.
+// Если какого-то шаблона нет, используется встроенный
+func LoadDigest(dir string) (*DigestRenderer, error) {
+	htmlText, err := readTemplate(dir, digestHTMLName, DefaultDigestHTMLTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	textText, err := readTemplate(dir, digestTextName, DefaultDigestTextTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &DigestRenderer{}
+
+	// html/template сам экранирует данные, поэтому в шаблонах дайджеста нет хелперов разметки телеграма
+	r.html, err = htmltemplate.New(digestHTMLName).Funcs(htmltemplate.FuncMap(digestFuncs())).Option("missingkey=error").Parse(htmlText)
+	if err != nil {
+		return nil, err
+	}
+
+	r.text, err = template.New(digestTextName).Funcs(digestFuncs()).Option("missingkey=error").Parse(textText)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := Digest{Title: "Sample digest", Posts: []Post{samplePost}}
+	if _, _, err := r.Render(sample); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Рендерит дайджест и возвращает HTML и текстовую версии письма
+func (r *DigestRenderer) Render(digest Digest) (string, string, error) {
+	var html, text bytes.Buffer
+
+	if err := r.html.Execute(&html, digest); err != nil {
+		return "", "", err
+	}
+
+	if err := r.text.Execute(&text, digest); err != nil {
+		return "", "", err
+	}
+
+	return html.String(), text.String(), nil
+}
+
+func digestFuncs() template.FuncMap {
+	return template.FuncMap{
+		"truncate": truncate,
+		"hashtags": Hashtags,
+		"date":     date,
+	}
+}
+
+// Читает шаблон name из каталога dir, а если его там нет, возвращает fallback
+func readTemplate(dir string, name string, fallback string) (string, error) {
+	if dir == "" {
+		return fallback, nil
+	}
+
+	text, err := os.ReadFile(filepath.Join(dir, name+templateExt))
+	if os.IsNotExist(err) {
+		return fallback, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return string(text), nil
+}
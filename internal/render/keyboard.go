@@ -0,0 +1,100 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+)
+
+// Префикс данных кнопок реакций, по нему бот роутит нажатия
+const ReactionCallbackPrefix = "react"
+
+// Настройки inline клавиатуры под постом
+type Keyboard struct {
+	// Показывать кнопку со ссылкой на статью
+	ReadButton bool
+	// Ссылка на группу для обсуждения. Пустая ссылка - без кнопки
+	DiscussURL string
+	// Показывать кнопку со ссылкой на ленту источника
+	SourceButton bool
+	// Набор реакций, например 👍 и 👎
+	Reactions []string
+}
+
+// Собирает клавиатуру для поста со статьей. counts - текущее количество каждой реакции.
+// Возвращает nil, если под постом не должно быть ни одной кнопки
+func (k Keyboard) Build(article model.Article, source model.Source, counts map[string]int) *tgbotapi.InlineKeyboardMarkup {
+	var (
+		rows  [][]tgbotapi.InlineKeyboardButton
+		links []tgbotapi.InlineKeyboardButton
+	)
+
+	if k.ReadButton {
+		links = append(links, tgbotapi.NewInlineKeyboardButtonURL("Читать статью", article.Link))
+	}
+
+	if k.DiscussURL != "" {
+		links = append(links, tgbotapi.NewInlineKeyboardButtonURL("Обсудить", k.DiscussURL))
+	}
+
+	if k.SourceButton && source.FeedURL != "" {
+		links = append(links, tgbotapi.NewInlineKeyboardButtonURL("Источник", source.FeedURL))
+	}
+
+	if len(links) > 0 {
+		rows = append(rows, links)
+	}
+
+	if len(k.Reactions) > 0 {
+		reactions := make([]tgbotapi.InlineKeyboardButton, 0, len(k.Reactions))
+
+		for i, reaction := range k.Reactions {
+			label := reaction
+			if count := counts[reaction]; count > 0 {
+				label = fmt.Sprintf("%s %d", reaction, count)
+			}
+
+			// В данных кнопки передаем индекс реакции, а не ее саму, чтобы уложиться в лимит телеграма на размер данных
+			reactions = append(reactions, tgbotapi.NewInlineKeyboardButtonData(
+				label,
+				botkit.CallbackData(ReactionCallbackPrefix, strconv.FormatInt(article.ID, 10), strconv.Itoa(i)),
+			))
+		}
+
+		rows = append(rows, reactions)
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &markup
+}
+
+// Префикс данных кнопок модерации и действия редактора
+const (
+	ModerationCallbackPrefix = "moderate"
+
+	ModerationActionApprove = "approve"
+	ModerationActionReject  = "reject"
+	ModerationActionEdit    = "edit"
+)
+
+// Собирает клавиатуру с решениями редактора для статьи в очереди модерации
+func ModerationKeyboard(articleID int64) tgbotapi.InlineKeyboardMarkup {
+	id := strconv.FormatInt(articleID, 10)
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Одобрить", botkit.CallbackData(ModerationCallbackPrefix, ModerationActionApprove, id)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отклонить", botkit.CallbackData(ModerationCallbackPrefix, ModerationActionReject, id)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✏️ Изменить summary", botkit.CallbackData(ModerationCallbackPrefix, ModerationActionEdit, id)),
+		),
+	)
+}
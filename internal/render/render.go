@@ -0,0 +1,278 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/kovalyov-valentin/news-feed-bot/internal/botkit/markup"
+)
+
+// Шаблон по умолчанию повторяет прежний формат поста: жирный заголовок, summary и ссылка на статью.
+// Структурированное summary выводится списком
+const DefaultTemplate = `{{ bold .Title }}{{ if .Bullets }}
+
+{{ italic .TLDR }}
+{{ range .Bullets }}
+• {{ escape . }}{{ end }}{{ else if .Summary }}
+
+{{ escape .Summary }}{{ end }}{{ if .Paywalled }}
+
+🔒 Статья доступна только по подписке{{ end }}
+
+{{ escape .Link }}`
+
+const (
+	// Имя глобального шаблона
+	defaultName = "default"
+	// Префиксы шаблонов для источников и для мест назначения
+	sourcePrefix      = "source_"
+	destinationPrefix = "destination_"
+	// Расширение файлов с шаблонами
+	templateExt = ".tmpl"
+)
+
+// Средняя скорость чтения, слов в минуту
+const wordsPerMinute = 200
+
+// Данные статьи, которые доступны в шаблоне поста
+type Post struct {
+	ID      int64
+	Title   string
+	Link    string
+	Summary string
+	// Структурированное summary: главная мысль, ключевые факты и упомянутые сущности.
+	// Пустые, если summary обычное, тогда его текст только в Summary
+	TLDR     string
+	Bullets  []string
+	Entities []string
+	// Полный текст статьи закрыт paywall, поэтому summary нет
+	Paywalled  bool
+	SourceName string
+	Categories []string
+	// Теги из словаря, которые проставила LLM
+	Tags        []string
+	PublishedAt time.Time
+	// Время чтения статьи в минутах
+	ReadingTime int
+	// Заголовок статьи на языке оригинала. Совпадает с Title, если статья не переводилась
+	OriginalTitle string
+	// Язык поста и язык оригинала статьи, пустой если язык не определен
+	Language         string
+	OriginalLanguage string
+	// Переведены ли заголовок и summary на язык места назначения
+	Translated bool
+}
+
+// Темы поста для хэштегов: теги из словаря, если статья классифицирована, иначе категории из ленты.
+// То же правило, что и в шаблоне по умолчанию
+func (p Post) Topics() []string {
+	if len(p.Tags) > 0 {
+		return p.Tags
+	}
+	return p.Categories
+}
+
+// Набор шаблонов постов.
+// Шаблоны ищутся от более конкретного к общему: сначала шаблон источника, затем места назначения, затем глобальный
+type Renderer struct {
+	// Режим разметки, под который экранируется текст в шаблонах
+	mode      markup.Mode
+	templates map[string]*template.Template
+}
+
+// Загружает шаблоны из каталога dir.
+// В каталоге могут лежать default.tmpl, source_<id источника>.tmpl и destination_<имя места назначения>.tmpl,
+// а также шаблоны дайджеста, которые здесь пропускаются.
+// Если глобального шаблона нет, используется DefaultTemplate. Пустой dir означает, что используются только встроенные шаблоны.
+// Хелперы escape, bold, link и другие рендерят разметку в режиме mode
+func Load(dir string, mode markup.Mode) (*Renderer, error) {
+	r := &Renderer{mode: mode, templates: make(map[string]*template.Template)}
+
+	if err := r.add(defaultName, DefaultTemplate); err != nil {
+		return nil, err
+	}
+
+	if dir == "" {
+		return r, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+templateExt))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimSuffix(filepath.Base(path), templateExt)
+		// Шаблоны дайджеста загружаются отдельно, в LoadDigest
+		if name == digestHTMLName || name == digestTextName {
+			continue
+		}
+
+		if name != defaultName && !strings.HasPrefix(name, sourcePrefix) && !strings.HasPrefix(name, destinationPrefix) {
+			return nil, fmt.Errorf("unexpected template file %s", path)
+		}
+
+		if err := r.add(name, string(text)); err != nil {
+			return nil, fmt.Errorf("template %s: %w", path, err)
+		}
+	}
+
+	return r, nil
+}
+
+// Разбирает шаблон и сразу пробует отрендерить его на тестовых данных, чтобы ошибки всплыли при старте, а не при отправке
+func (r *Renderer) add(name string, text string) error {
+	tmpl, err := template.New(name).Funcs(r.funcs()).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return err
+	}
+
+	if err := tmpl.Execute(&bytes.Buffer{}, samplePost); err != nil {
+		return err
+	}
+
+	r.templates[name] = tmpl
+	return nil
+}
+
+// Рендерит пост для места назначения destination
+func (r *Renderer) Render(destination string, sourceID int64, post Post) (string, error) {
+	var buf bytes.Buffer
+	if err := r.lookup(destination, sourceID).Execute(&buf, post); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func (r *Renderer) lookup(destination string, sourceID int64) *template.Template {
+	names := []string{
+		fmt.Sprintf("%s%d", sourcePrefix, sourceID),
+		destinationPrefix + destination,
+	}
+
+	for _, name := range names {
+		if tmpl, ok := r.templates[name]; ok {
+			return tmpl
+		}
+	}
+
+	return r.templates[defaultName]
+}
+
+// Оценка времени чтения текста в минутах, но не меньше одной минуты
+func ReadingTime(text string) int {
+	minutes := len(strings.Fields(text)) / wordsPerMinute
+	if minutes < 1 {
+		return 1
+	}
+
+	return minutes
+}
+
+// Режим разметки, в котором нужно отправлять отрендеренные посты
+func (r *Renderer) Mode() markup.Mode {
+	return r.mode
+}
+
+// Хелперы, доступные в шаблонах
+func (r *Renderer) funcs() template.FuncMap {
+	// Рендерит один элемент разметки в режиме рендерера
+	node := func(n markup.Node) string {
+		return markup.New(n).Render(r.mode)
+	}
+
+	return template.FuncMap{
+		"escape": r.mode.Escape,
+		"bold": func(s string) string {
+			return node(markup.Bold(markup.Text(s)))
+		},
+		"italic": func(s string) string {
+			return node(markup.Italic(markup.Text(s)))
+		},
+		"spoiler": func(s string) string {
+			return node(markup.Spoiler(markup.Text(s)))
+		},
+		"code": func(s string) string {
+			return node(markup.Code(s))
+		},
+		"blockquote": func(s string) string {
+			return node(markup.Blockquote(markup.Text(s)))
+		},
+		"link": func(text string, url string) string {
+			return node(markup.Link(url, markup.Text(text)))
+		},
+		"truncate": truncate,
+		"hashtags": Hashtags,
+		"join":     strings.Join,
+		"date":     date,
+	}
+}
+
+// Обрезает строку до n символов, добавляя многоточие. Символы считаются в UTF-16, как их считает телеграм.
+// Аргументы в таком порядке, чтобы функцию можно было вызывать в конвейере шаблона: {{ .Summary | truncate 200 }}
+func truncate(n int, s string) string {
+	return markup.Truncate(s, n)
+}
+
+// Пометка статьи по подписке для площадок без шаблонов, как в шаблоне по умолчанию
+const PaywallMarker = "🔒 по подписке"
+
+// Превращает категории в хэштеги: #go #базы_данных.
+// Символы, которые телеграм не считает частью хэштега, заменяются на подчеркивание
+func Hashtags(categories []string) string {
+	tags := make([]string, 0, len(categories))
+
+	for _, category := range categories {
+		tag := strings.Trim(strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToLower(r)
+			}
+			return '_'
+		}, strings.TrimSpace(category)), "_")
+
+		if tag == "" {
+			continue
+		}
+
+		tags = append(tags, "#"+tag)
+	}
+
+	return strings.Join(tags, " ")
+}
+
+// Форматирует время по layout из пакета time
+func date(layout string, t time.Time) string {
+	return t.Format(layout)
+}
+
+// Тестовые данные для проверки шаблонов при загрузке
+var samplePost = Post{
+	ID:          1,
+	Title:       "Sample title",
+	Link:        "https://example.com/article",
+	Summary:     "Sample summary.\n\n• First fact\n• Second fact",
+	TLDR:        "Sample summary.",
+	Bullets:     []string{"First fact", "Second fact"},
+	Entities:    []string{"Go", "PostgreSQL"},
+	SourceName:  "Sample source",
+	Categories:  []string{"go", "databases"},
+	Tags:        []string{"go"},
+	PublishedAt: time.Date(2023, time.July, 1, 12, 0, 0, 0, time.UTC),
+	ReadingTime: 3,
+
+	OriginalTitle:    "Sample title",
+	Language:         "en",
+	OriginalLanguage: "en",
+}
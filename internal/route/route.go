@@ -0,0 +1,57 @@
+package route
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Правила маршрутизации: для места назначения - значения, статьи с которыми оно принимает.
+// Места назначения, которых нет в правилах, принимают любые статьи
+type Routes map[string][]string
+
+// Разбирает правила в формате "место назначения:значение|значение", например "telegram:ru|en"
+func Parse(routes []string) (Routes, error) {
+	result := make(Routes, len(routes))
+
+	for _, route := range routes {
+		if strings.TrimSpace(route) == "" {
+			continue
+		}
+
+		destination, values, ok := strings.Cut(route, ":")
+		destination = strings.TrimSpace(destination)
+		if !ok || destination == "" {
+			return nil, fmt.Errorf("invalid route %q, expected destination:value|value", route)
+		}
+
+		for _, value := range strings.Split(values, "|") {
+			if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+				result[destination] = append(result[destination], value)
+			}
+		}
+
+		if len(result[destination]) == 0 {
+			return nil, fmt.Errorf("no values in route %q", route)
+		}
+	}
+
+	return result, nil
+}
+
+// Принимает ли место назначения destination статью с одним из значений values
+func (r Routes) Allows(destination string, values ...string) bool {
+	allowed, ok := r[destination]
+	if !ok {
+		return true
+	}
+
+	for _, value := range values {
+		for _, a := range allowed {
+			if strings.EqualFold(a, value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -49,6 +49,7 @@ func (s RSSSource) Fetch(ctx context.Context) ([]model.Item, error) {
 	var items []model.Item
 	for _, item := range feed.Items {
 		items = append(items, model.Item{
+			GUID:       item.ID,
 			Title:      item.Title,
 			Categories: item.Categories,
 			Link:       item.Link,
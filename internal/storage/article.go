@@ -2,8 +2,13 @@ package storage
 
 import (
 	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"github.com/jmoiron/sqlx"
 	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/lib/pq"
 	"github.com/samber/lo"
 	"time"
 )
@@ -16,7 +21,10 @@ func NewArticleStorage(db *sqlx.DB) *ArticlePostgresStorage {
 	return &ArticlePostgresStorage{db: db}
 }
 
-// Метод для сохранения статьи в базу данных
+// Метод для сохранения статьи в базу данных.
+// Если статья с таким GUID уже есть, но у нее поменялся заголовок или summary, статья обновляется,
+// а если она уже опубликована, то помечается для редактирования поста.
+// Язык, определенный по полному тексту статьи, не заменяется определенным по ленте
 func (s *ArticlePostgresStorage) Store(ctx context.Context, article model.Article) error {
 	conn, err := s.db.Connx(ctx)
 	if err != nil {
@@ -24,15 +32,49 @@ func (s *ArticlePostgresStorage) Store(ctx context.Context, article model.Articl
 	}
 	defer conn.Close()
 
+	res, err := conn.ExecContext(
+		ctx,
+		`UPDATE articles
+			SET title = $1,
+				summary = $2,
+				categories = $3,
+				language = CASE WHEN language_detected THEN language ELSE COALESCE(NULLIF($6, ''), language) END,
+				edit_pending = posted_at IS NOT NULL
+			WHERE source_id = $4
+			  AND guid = $5
+			  AND (title <> $1 OR COALESCE(summary, '') <> $2)`,
+		article.Title,
+		article.Summary,
+		pq.Array(article.Categories),
+		article.SourceID,
+		article.GUID,
+		article.Language,
+	)
+	if err != nil {
+		return err
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updated > 0 {
+		return nil
+	}
+
 	if _, err := conn.ExecContext(
 		ctx,
-		`INSERT INTO articles (source_id, title, link, summary, published_at)
-		VALUES ($1, $2, $3, $4, $5)
+		`INSERT INTO articles (source_id, guid, title, link, summary, categories, language, published_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
 		ON CONFLICT DO NOTHING`,
 		article.SourceID,
+		article.GUID,
 		article.Title,
 		article.Link,
 		article.Summary,
+		pq.Array(article.Categories),
+		article.Language,
 		article.PublishedAt,
 	); err != nil {
 		return err
@@ -40,8 +82,682 @@ func (s *ArticlePostgresStorage) Store(ctx context.Context, article model.Articl
 	return nil
 }
 
-// Возвращает все статьи, которые не были запощены в телеграм, начиная с определенного времени
-func (s *ArticlePostgresStorage) AllNotPosted(ctx context.Context, since time.Time, limit uint64) ([]model.Article, error) {
+// Метод для получения статьи по ее id
+func (s *ArticlePostgresStorage) ArticleByID(ctx context.Context, id int64) (*model.Article, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var article dbArticle
+	if err := conn.GetContext(ctx, &article, `SELECT * FROM articles WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+
+	result := article.toModel()
+	return &result, nil
+}
+
+// Атомарно захватывает следующую статью для отправки.
+// Строка блокируется через FOR UPDATE SKIP LOCKED, поэтому несколько запущенных инстансов никогда не получат одну и ту же статью.
+// Захват действует до claimed_until: если инстанс упал и не отпустил статью, после истечения lease ее заберет другой.
+// Каждому захвату выдается свой токен, по нему последующие изменения проверяют, что захват еще не истек и не перехвачен.
+// Окно since не применяется к статьям с запланированной попыткой: повторам после ошибки и статьям, возвращенным в очередь админом.
+// Выбираются только статьи с заданным статусом модерации, пустой статус - статьи, которые еще не проходили модерацию.
+// Возвращает nil, если подходящих статей нет
+func (s *ArticlePostgresStorage) ClaimNext(
+	ctx context.Context,
+	since time.Time,
+	lease time.Duration,
+	status model.ModerationStatus,
+) (*model.Article, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	now := time.Now().UTC()
+
+	token, err := claimToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var article dbArticle
+	if err := conn.GetContext(
+		ctx,
+		&article,
+		`UPDATE articles
+			SET claimed_until = $1::timestamp,
+				claim_token = $5
+			WHERE id = (
+				SELECT id FROM articles
+				WHERE posted_at IS NULL
+				  AND dead_at IS NULL
+				  AND dropped_at IS NULL
+				  AND skipped_at IS NULL
+				  AND (next_attempt_at IS NULL OR next_attempt_at <= $2::timestamp)
+				  AND (claimed_until IS NULL OR claimed_until <= $2::timestamp)
+				  AND (published_at >= $3::timestamp OR next_attempt_at IS NOT NULL)
+				  AND moderation_status IS NOT DISTINCT FROM $4
+				ORDER BY published_at DESC
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *`,
+		now.Add(lease).Format(time.RFC3339),
+		now.Format(time.RFC3339),
+		since.UTC().Format(time.RFC3339),
+		sql.NullString{String: string(status), Valid: status != ""},
+		token,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	result := article.toModel()
+	return &result, nil
+}
+
+func claimToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Продлевает захват статьи на lease, пока инстанс долго готовит ее к отправке.
+// Если захват уже истек или его перехватил другой инстанс, возвращает model.ErrClaimLost
+func (s *ArticlePostgresStorage) ExtendClaim(ctx context.Context, id int64, token string, lease time.Duration) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	now := time.Now().UTC()
+
+	res, err := conn.ExecContext(
+		ctx,
+		`UPDATE articles
+			SET claimed_until = $1::timestamp
+			WHERE id = $2 AND claim_token = $3 AND claimed_until > $4::timestamp`,
+		now.Add(lease).Format(time.RFC3339),
+		id,
+		token,
+		now.Format(time.RFC3339),
+	)
+	if err != nil {
+		return err
+	}
+
+	return claimResult(res)
+}
+
+// Проверяет, что изменение по захваченной статье прошло, то есть захват еще действовал
+func claimResult(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return model.ErrClaimLost
+	}
+
+	return nil
+}
+
+// Отмечает, что статья отправлена редакторам на модерацию, и отпускает ее
+func (s *ArticlePostgresStorage) MarkSentForModeration(ctx context.Context, id int64, messageID int) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`UPDATE articles
+			SET moderation_status = $1,
+				moderation_message_id = $2,
+				moderation_sent_at = $3::timestamp,
+				claimed_until = NULL
+			WHERE id = $4`,
+		model.ModerationPending,
+		messageID,
+		time.Now().UTC().Format(time.RFC3339),
+		id,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Записывает решение редактора по статье, которая ждет модерации.
+// Одобренная статья ставится в очередь на публикацию сразу, независимо от окна отправки.
+// Если статья не ждет модерации (например, решение уже принято), возвращается sql.ErrNoRows
+func (s *ArticlePostgresStorage) Moderate(
+	ctx context.Context,
+	id int64,
+	status model.ModerationStatus,
+	userID int64,
+	userName string,
+) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	res, err := conn.ExecContext(
+		ctx,
+		`UPDATE articles
+			SET moderation_status = $1,
+				moderated_by = $2,
+				moderated_by_name = $3,
+				moderated_at = $4::timestamp,
+				next_attempt_at = CASE WHEN $1 = 'approved' THEN $4::timestamp ELSE next_attempt_at END
+			WHERE id = $5 AND moderation_status = $6`,
+		status,
+		userID,
+		userName,
+		now,
+		id,
+		model.ModerationPending,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Сохраняет summary, исправленное редактором
+func (s *ArticlePostgresStorage) SetEditedSummary(ctx context.Context, id int64, summary string) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.ExecContext(
+		ctx,
+		`UPDATE articles SET edited_summary = $1 WHERE id = $2 AND posted_at IS NULL`,
+		summary,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Переводит в expired статьи, по которым никто не принял решения с момента before.
+// Возвращает количество таких статей
+func (s *ArticlePostgresStorage) ExpireModeration(ctx context.Context, before time.Time) (int64, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	res, err := conn.ExecContext(
+		ctx,
+		`UPDATE articles
+			SET moderation_status = $1
+			WHERE moderation_status = $2 AND moderation_sent_at < $3::timestamp`,
+		model.ModerationExpired,
+		model.ModerationPending,
+		before.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Отпускает захваченную статью, не меняя ее состояния, чтобы ее мог забрать любой инстанс.
+// Статью, которую уже захватил другой инстанс, не трогает
+func (s *ArticlePostgresStorage) Release(ctx context.Context, id int64, token string) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`UPDATE articles SET claimed_until = NULL WHERE id = $1 AND claim_token = $2`,
+		id,
+		token,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Метод, чтобы отметить статью, как запощенную, чтобы не постить ее в будущем.
+// Вызывается, когда статья доставлена во все места назначения. Вместе с отметкой сохраняется опубликованный summary.
+// Если захват статьи с токеном token уже истек, возвращает model.ErrClaimLost
+func (s *ArticlePostgresStorage) MarkPosted(ctx context.Context, id int64, token string, summary string) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	res, err := conn.ExecContext(
+		ctx,
+		`UPDATE articles 
+			SET posted_at = $1::timestamp,
+				published_summary = $2,
+				claimed_until = NULL
+			WHERE id = $3 AND claim_token = $4 AND claimed_until > $1::timestamp`,
+		now,
+		summary,
+		id,
+		token,
+	)
+	if err != nil {
+		return err
+	}
+
+	return claimResult(res)
+}
+
+// Обновляет опубликованный summary после редактирования поста
+func (s *ArticlePostgresStorage) SetPublishedSummary(ctx context.Context, id int64, summary string) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`UPDATE articles SET published_summary = $1 WHERE id = $2`,
+		summary,
+		id,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Сохраняет язык статьи, уточненный по полному тексту статьи
+func (s *ArticlePostgresStorage) SetLanguage(ctx context.Context, id int64, language string) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`UPDATE articles SET language = NULLIF($1, ''), language_detected = TRUE WHERE id = $2`,
+		language,
+		id,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Отмечает, что полный текст статьи недоступен, или снимает отметку
+func (s *ArticlePostgresStorage) SetPaywalled(ctx context.Context, id int64, paywalled bool) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `UPDATE articles SET paywalled = $1 WHERE id = $2`, paywalled, id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Сохраняет теги статьи и отмечает, что статья классифицирована
+func (s *ArticlePostgresStorage) SetTags(ctx context.Context, id int64, tags []string) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`UPDATE articles SET tags = $1, tagged_at = $2::timestamp WHERE id = $3`,
+		pq.Array(tags),
+		time.Now().UTC().Format(time.RFC3339),
+		id,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Убирает статью из очереди, запоминая причину, и отпускает ее
+func (s *ArticlePostgresStorage) Skip(ctx context.Context, id int64, reason string) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`UPDATE articles
+			SET skipped_at = $1::timestamp,
+				skip_reason = $2,
+				claimed_until = NULL
+			WHERE id = $3`,
+		time.Now().UTC().Format(time.RFC3339),
+		reason,
+		id,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Помечает опубликованную статью для редактирования поста, например после повторной генерации summary.
+// Если статья еще не опубликована, ничего не делает: при отправке и так будет использован свежий summary
+func (s *ArticlePostgresStorage) RequestEdit(ctx context.Context, id int64) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`UPDATE articles SET edit_pending = TRUE WHERE id = $1 AND posted_at IS NOT NULL AND dropped_at IS NULL`,
+		id,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Сохраняет успешную доставку статьи в место назначения.
+// Для телеграма вместе с ней сохраняется отправленное сообщение, чтобы пост можно было потом отредактировать или удалить.
+// Доставка сохраняется, только пока действует захват статьи с токеном token, иначе возвращается model.ErrClaimLost
+func (s *ArticlePostgresStorage) MarkDelivered(ctx context.Context, delivery model.Delivery, token string) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	res, err := conn.ExecContext(
+		ctx,
+		`INSERT INTO deliveries (article_id, destination, status, chat_id, message_id, attempts, delivered_at, updated_at)
+		SELECT $1, $2, $3, $4, $5, 1, $6::timestamp, $6::timestamp
+		WHERE EXISTS (SELECT 1 FROM articles WHERE id = $1 AND claim_token = $7 AND claimed_until > $6::timestamp)
+		ON CONFLICT (article_id, destination) DO UPDATE
+			SET status = EXCLUDED.status,
+				chat_id = EXCLUDED.chat_id,
+				message_id = EXCLUDED.message_id,
+				attempts = deliveries.attempts + 1,
+				last_error = NULL,
+				delivered_at = EXCLUDED.delivered_at,
+				updated_at = EXCLUDED.updated_at`,
+		delivery.ArticleID,
+		delivery.Destination,
+		model.DeliveryDelivered,
+		sql.NullInt64{Int64: delivery.ChatID, Valid: delivery.ChatID != 0},
+		sql.NullInt64{Int64: int64(delivery.MessageID), Valid: delivery.MessageID != 0},
+		now,
+		token,
+	)
+	if err != nil {
+		return err
+	}
+
+	return claimResult(res)
+}
+
+// Сохраняет неудачную попытку доставки статьи в место назначения.
+// Как и MarkDelivered, работает, только пока действует захват статьи с токеном token
+func (s *ArticlePostgresStorage) MarkDeliveryFailed(
+	ctx context.Context,
+	articleID int64,
+	token string,
+	destination string,
+	reason string,
+) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.ExecContext(
+		ctx,
+		`INSERT INTO deliveries (article_id, destination, status, attempts, last_error, updated_at)
+		SELECT $1, $2, $3, 1, $4, $5::timestamp
+		WHERE EXISTS (SELECT 1 FROM articles WHERE id = $1 AND claim_token = $6 AND claimed_until > $5::timestamp)
+		ON CONFLICT (article_id, destination) DO UPDATE
+			SET status = EXCLUDED.status,
+				attempts = deliveries.attempts + 1,
+				last_error = EXCLUDED.last_error,
+				updated_at = EXCLUDED.updated_at`,
+		articleID,
+		destination,
+		model.DeliveryFailed,
+		reason,
+		time.Now().UTC().Format(time.RFC3339),
+		token,
+	)
+	if err != nil {
+		return err
+	}
+
+	return claimResult(res)
+}
+
+// Возвращает доставки статьи во все места назначения
+func (s *ArticlePostgresStorage) Deliveries(ctx context.Context, articleID int64) ([]model.Delivery, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var deliveries []dbDelivery
+	if err := conn.SelectContext(
+		ctx,
+		&deliveries,
+		`SELECT * FROM deliveries WHERE article_id = $1`,
+		articleID,
+	); err != nil {
+		return nil, err
+	}
+
+	return lo.Map(deliveries, func(delivery dbDelivery, _ int) model.Delivery {
+		return delivery.toModel()
+	}), nil
+}
+
+// Захватывает следующую опубликованную статью, которая изменилась в источнике и пост с которой нужно отредактировать.
+// Флаг снимается сразу при захвате: редактирование делается по возможности и не повторяется при ошибке.
+// Возвращает nil, если таких статей нет
+func (s *ArticlePostgresStorage) ClaimEdit(ctx context.Context) (*model.Article, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var article dbArticle
+	if err := conn.GetContext(
+		ctx,
+		&article,
+		`UPDATE articles
+			SET edit_pending = FALSE
+			WHERE id = (
+				SELECT id FROM articles
+				WHERE edit_pending
+				  AND posted_at IS NOT NULL
+				ORDER BY published_at DESC
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *`,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	result := article.toModel()
+	return &result, nil
+}
+
+// Снимает статью с публикации: забывает доставки во все места назначения.
+// Если requeue, статья возвращается в очередь на отправку, иначе помечается как снятая и больше не отправляется
+func (s *ArticlePostgresStorage) Unpost(ctx context.Context, id int64, requeue bool) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM deliveries WHERE article_id = $1`, id); err != nil {
+		return err
+	}
+
+	var res sql.Result
+	if requeue {
+		res, err = tx.ExecContext(
+			ctx,
+			`UPDATE articles
+			SET posted_at = NULL,
+				edit_pending = FALSE,
+				post_attempts = 0,
+				last_error = NULL,
+				next_attempt_at = $1::timestamp,
+				dead_at = NULL,
+				dropped_at = NULL,
+				claimed_until = NULL
+			WHERE id = $2`,
+			time.Now().UTC().Format(time.RFC3339),
+			id,
+		)
+	} else {
+		res, err = tx.ExecContext(
+			ctx,
+			`UPDATE articles
+			SET posted_at = NULL,
+				edit_pending = FALSE,
+				dropped_at = $1::timestamp
+			WHERE id = $2`,
+			time.Now().UTC().Format(time.RFC3339),
+			id,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+// Метод, чтобы зафиксировать неудачную попытку отправки статьи.
+// Увеличивает счетчик попыток, запоминает ошибку и время следующей попытки.
+// Если попытки исчерпаны, статья переносится в dead-letter и больше не выбирается для отправки.
+// maxAttempts <= 0 означает, что число попыток не ограничено.
+// Возвращает true, если статья оказалась в dead-letter
+func (s *ArticlePostgresStorage) MarkFailed(
+	ctx context.Context,
+	id int64,
+	reason string,
+	nextAttemptAt time.Time,
+	maxAttempts int,
+) (bool, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	var dead bool
+
+	if err := conn.QueryRowxContext(
+		ctx,
+		`UPDATE articles
+			SET post_attempts = post_attempts + 1,
+				last_error = $1,
+				next_attempt_at = $2::timestamp,
+				dead_at = CASE WHEN $3 > 0 AND post_attempts + 1 >= $3 THEN $4::timestamp END,
+				claimed_until = NULL
+			WHERE id = $5
+			RETURNING dead_at IS NOT NULL`,
+		reason,
+		nextAttemptAt.UTC().Format(time.RFC3339),
+		maxAttempts,
+		time.Now().UTC().Format(time.RFC3339),
+		id,
+	).Scan(&dead); err != nil {
+		return false, err
+	}
+
+	return dead, nil
+}
+
+// Возвращает статьи, которые находятся в dead-letter, начиная с самых свежих
+func (s *ArticlePostgresStorage) AllDead(ctx context.Context, limit uint64) ([]model.Article, error) {
 	conn, err := s.db.Connx(ctx)
 	if err != nil {
 		return nil, err
@@ -52,60 +768,230 @@ func (s *ArticlePostgresStorage) AllNotPosted(ctx context.Context, since time.Ti
 	if err := conn.SelectContext(
 		ctx,
 		&articles,
-		`SELECT * FROM articles 
-         WHERE posted_at IS NULL 
-           AND published_at >= $1::timestamp
-         ORDER BY published_at DESC 
-         LIMIT $2`,
-		since.UTC().Format(time.RFC3339),
+		`SELECT * FROM articles
+         WHERE dead_at IS NOT NULL
+           AND dropped_at IS NULL
+           AND posted_at IS NULL
+         ORDER BY dead_at DESC
+         LIMIT $1`,
 		limit,
 	); err != nil {
 		return nil, err
 	}
 
 	return lo.Map(articles, func(article dbArticle, _ int) model.Article {
-		return model.Article{
-			ID:       article.ID,
-			SourceID: article.SourceID,
-			Title:    article.Title,
-			Link:     article.Link,
-			Summary:  article.Summary,
-			//PostedAt:    article.PostedAt,
-			PublishedAt: article.PublishedAt,
-			CreatedAt:   article.CreatedAt,
-		}
+		return article.toModel()
 	}), nil
 }
 
-// Метод, чтобы отметить статью, как запощенную, чтобы не постить ее в будущем
-func (s *ArticlePostgresStorage) MarkPosted(ctx context.Context, id int64) error {
+// Опубликованные статьи для исходящей ленты, от новых к старым.
+// Пустые destination и category означают, что фильтр по ним не применяется
+func (s *ArticlePostgresStorage) Posted(ctx context.Context, destination string, category string, limit uint64, offset uint64) ([]model.Article, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var articles []dbArticle
+	if err := conn.SelectContext(
+		ctx,
+		&articles,
+		`SELECT * FROM articles a
+         WHERE a.posted_at IS NOT NULL
+           AND a.dropped_at IS NULL
+           AND ($1::text = '' OR EXISTS (
+               SELECT 1 FROM deliveries d
+               WHERE d.article_id = a.id AND d.destination = $1::text AND d.status = 'delivered'
+           ))
+           AND ($2::text = '' OR EXISTS (
+               SELECT 1 FROM unnest(a.categories || a.tags) c WHERE lower(c) = lower($2::text)
+           ))
+         ORDER BY a.posted_at DESC, a.id DESC
+         LIMIT $3 OFFSET $4`,
+		destination,
+		category,
+		limit,
+		offset,
+	); err != nil {
+		return nil, err
+	}
+
+	return lo.Map(articles, func(article dbArticle, _ int) model.Article {
+		return article.toModel()
+	}), nil
+}
+
+// Статьи, опубликованные в промежутке (from, to], в порядке публикации. Используется для дайджеста
+func (s *ArticlePostgresStorage) PostedBetween(ctx context.Context, from time.Time, to time.Time) ([]model.Article, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var articles []dbArticle
+	if err := conn.SelectContext(
+		ctx,
+		&articles,
+		`SELECT * FROM articles
+         WHERE posted_at > $1::timestamp
+           AND posted_at <= $2::timestamp
+           AND dropped_at IS NULL
+         ORDER BY posted_at`,
+		from.UTC().Format(time.RFC3339),
+		to.UTC().Format(time.RFC3339),
+	); err != nil {
+		return nil, err
+	}
+
+	return lo.Map(articles, func(article dbArticle, _ int) model.Article {
+		return article.toModel()
+	}), nil
+}
+
+// Метод, чтобы вернуть статью из dead-letter обратно в очередь на отправку.
+// Счетчик попыток и последняя ошибка сбрасываются. Пропущенная статья снова проходит фильтры,
+// а отклоненная или снятая с модерации по таймауту снова уходит редакторам.
+// Статью, которая ждет решения редакторов, вернуть нельзя: она и так в работе
+func (s *ArticlePostgresStorage) Retry(ctx context.Context, id int64) error {
 	conn, err := s.db.Connx(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	if _, err := conn.ExecContext(
+	res, err := conn.ExecContext(
 		ctx,
-		`UPDATE articles 
-			SET posted_at = $1::timestamp 
-			WHERE id = $2`,
+		`UPDATE articles
+			SET post_attempts = 0,
+				last_error = NULL,
+				next_attempt_at = $1::timestamp,
+				dead_at = NULL,
+				skipped_at = NULL,
+				skip_reason = NULL,
+				moderation_status = CASE WHEN moderation_status = $3 THEN moderation_status END,
+				moderation_message_id = CASE WHEN moderation_status = $3 THEN moderation_message_id END,
+				moderation_sent_at = CASE WHEN moderation_status = $3 THEN moderation_sent_at END
+			WHERE id = $2 AND posted_at IS NULL AND dropped_at IS NULL
+			  AND moderation_status IS DISTINCT FROM $4`,
 		time.Now().UTC().Format(time.RFC3339),
 		id,
-	); err != nil {
+		model.ModerationApproved,
+		model.ModerationPending,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
 		return err
 	}
 
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
 	return nil
 }
 
+// Внутренняя модель статьи. Часть колонок может быть NULL, поэтому для них используются sql.Null* типы
 type dbArticle struct {
-	ID          int64     `db:"id"`
-	SourceID    int64     `db:"source_id"`
-	Title       string    `db:"title"`
-	Link        string    `db:"link"`
-	Summary     string    `db:"summary"`
-	PostedAt    time.Time `db:"posted_at"`
-	PublishedAt time.Time `db:"published_at"`
-	CreatedAt   time.Time `db:"created_at"`
+	ID            int64          `db:"id"`
+	SourceID      int64          `db:"source_id"`
+	Title         string         `db:"title"`
+	Link          string         `db:"link"`
+	Summary       sql.NullString `db:"summary"`
+	PostedAt      sql.NullTime   `db:"posted_at"`
+	PublishedAt   time.Time      `db:"published_at"`
+	CreatedAt     time.Time      `db:"created_at"`
+	PostAttempts  int            `db:"post_attempts"`
+	LastError     sql.NullString `db:"last_error"`
+	NextAttemptAt sql.NullTime   `db:"next_attempt_at"`
+	DeadAt        sql.NullTime   `db:"dead_at"`
+	ClaimedUntil  sql.NullTime   `db:"claimed_until"`
+	Categories    pq.StringArray `db:"categories"`
+	Language      sql.NullString `db:"language"`
+	Tags          pq.StringArray `db:"tags"`
+	TaggedAt      sql.NullTime   `db:"tagged_at"`
+	SkippedAt     sql.NullTime   `db:"skipped_at"`
+	SkipReason    sql.NullString `db:"skip_reason"`
+	Paywalled     bool           `db:"paywalled"`
+	GUID          sql.NullString `db:"guid"`
+	EditPending   bool           `db:"edit_pending"`
+	DroppedAt     sql.NullTime   `db:"dropped_at"`
+	ClaimToken    sql.NullString `db:"claim_token"`
+
+	LanguageDetected bool `db:"language_detected"`
+
+	ModerationStatus    sql.NullString `db:"moderation_status"`
+	ModerationMessageID sql.NullInt32  `db:"moderation_message_id"`
+	ModerationSentAt    sql.NullTime   `db:"moderation_sent_at"`
+	ModeratedBy         sql.NullInt64  `db:"moderated_by"`
+	ModeratedByName     sql.NullString `db:"moderated_by_name"`
+	ModeratedAt         sql.NullTime   `db:"moderated_at"`
+	EditedSummary       sql.NullString `db:"edited_summary"`
+	PublishedSummary    sql.NullString `db:"published_summary"`
+}
+
+func (a dbArticle) toModel() model.Article {
+	return model.Article{
+		ID:            a.ID,
+		SourceID:      a.SourceID,
+		GUID:          a.GUID.String,
+		Title:         a.Title,
+		Link:          a.Link,
+		Summary:       a.Summary.String,
+		Categories:    a.Categories,
+		Language:      a.Language.String,
+		Tags:          a.Tags,
+		TaggedAt:      a.TaggedAt.Time,
+		SkippedAt:     a.SkippedAt.Time,
+		SkipReason:    a.SkipReason.String,
+		Paywalled:     a.Paywalled,
+		PublishedAt:   a.PublishedAt,
+		PostedAt:      a.PostedAt.Time,
+		CreatedAt:     a.CreatedAt,
+		Attempts:      a.PostAttempts,
+		LastError:     a.LastError.String,
+		NextAttemptAt: a.NextAttemptAt.Time,
+		DeadAt:        a.DeadAt.Time,
+		DroppedAt:     a.DroppedAt.Time,
+		ClaimToken:    a.ClaimToken.String,
+
+		LanguageDetected: a.LanguageDetected,
+
+		ModerationStatus: model.ModerationStatus(a.ModerationStatus.String),
+		ModeratedBy:      a.ModeratedBy.Int64,
+		ModeratedByName:  a.ModeratedByName.String,
+		ModeratedAt:      a.ModeratedAt.Time,
+		EditedSummary:    a.EditedSummary.String,
+		PublishedSummary: a.PublishedSummary.String,
+	}
+}
+
+type dbDelivery struct {
+	ArticleID   int64          `db:"article_id"`
+	Destination string         `db:"destination"`
+	Status      string         `db:"status"`
+	ChatID      sql.NullInt64  `db:"chat_id"`
+	MessageID   sql.NullInt32  `db:"message_id"`
+	Attempts    int            `db:"attempts"`
+	LastError   sql.NullString `db:"last_error"`
+	DeliveredAt sql.NullTime   `db:"delivered_at"`
+	UpdatedAt   time.Time      `db:"updated_at"`
+}
+
+func (d dbDelivery) toModel() model.Delivery {
+	return model.Delivery{
+		ArticleID:   d.ArticleID,
+		Destination: d.Destination,
+		Status:      model.DeliveryStatus(d.Status),
+		ChatID:      d.ChatID.Int64,
+		MessageID:   int(d.MessageID.Int32),
+		Attempts:    d.Attempts,
+		LastError:   d.LastError.String,
+		DeliveredAt: d.DeliveredAt.Time,
+	}
 }
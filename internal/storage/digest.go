@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Хранилище email дайджеста: список получателей и время отправки прошлых дайджестов
+type DigestPostgresStorage struct {
+	db *sqlx.DB
+}
+
+func NewDigestStorage(db *sqlx.DB) *DigestPostgresStorage {
+	return &DigestPostgresStorage{db: db}
+}
+
+// Добавляет получателя дайджеста. Повторное добавление того же адреса ничего не меняет
+func (s *DigestPostgresStorage) AddRecipient(ctx context.Context, email string) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`INSERT INTO digest_recipients (email) VALUES ($1) ON CONFLICT DO NOTHING`,
+		email,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Удаляет получателя дайджеста. Если такого адреса нет, возвращает sql.ErrNoRows
+func (s *DigestPostgresStorage) RemoveRecipient(ctx context.Context, email string) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.ExecContext(ctx, `DELETE FROM digest_recipients WHERE email = $1`, email)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (s *DigestPostgresStorage) Recipients(ctx context.Context) ([]string, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var recipients []string
+	if err := conn.SelectContext(ctx, &recipients, `SELECT email FROM digest_recipients ORDER BY email`); err != nil {
+		return nil, err
+	}
+
+	return recipients, nil
+}
+
+// Время отправки последнего дайджеста. Если дайджест еще не отправлялся, возвращает нулевое время
+func (s *DigestPostgresStorage) LastSentAt(ctx context.Context) (time.Time, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer conn.Close()
+
+	var sentAt sql.NullTime
+	if err := conn.GetContext(ctx, &sentAt, `SELECT MAX(sent_at) FROM digest_runs`); err != nil {
+		return time.Time{}, err
+	}
+
+	return sentAt.Time, nil
+}
+
+// Запоминает, что дайджест за период до sentAt отправлен
+func (s *DigestPostgresStorage) MarkSent(ctx context.Context, sentAt time.Time, articlesCount int) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`INSERT INTO digest_runs (sent_at, articles_count) VALUES ($1::timestamp, $2)`,
+		sentAt.UTC().Format(time.RFC3339),
+		articlesCount,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
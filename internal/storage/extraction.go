@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/lib/pq"
+	"github.com/samber/lo"
+)
+
+// Хранилище правил извлечения текста статей для отдельных сайтов
+type ExtractionRulePostgresStorage struct {
+	db *sqlx.DB
+}
+
+func NewExtractionRuleStorage(db *sqlx.DB) *ExtractionRulePostgresStorage {
+	return &ExtractionRulePostgresStorage{db: db}
+}
+
+// Сохраняет правило для домена, заменяя прежнее
+func (s *ExtractionRulePostgresStorage) SetRule(ctx context.Context, rule model.ExtractionRule) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`INSERT INTO extraction_rules (domain, content_selector, strip_selectors, paywall_selector, updated_at)
+		VALUES ($1, $2, $3, $4, $5::timestamp)
+		ON CONFLICT (domain) DO UPDATE
+			SET content_selector = EXCLUDED.content_selector,
+				strip_selectors = EXCLUDED.strip_selectors,
+				paywall_selector = EXCLUDED.paywall_selector,
+				updated_at = EXCLUDED.updated_at`,
+		rule.Domain,
+		rule.ContentSelector,
+		pq.Array(rule.StripSelectors),
+		rule.PaywallSelector,
+		time.Now().UTC().Format(time.RFC3339),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Удаляет правило домена. Если правила нет, возвращает sql.ErrNoRows
+func (s *ExtractionRulePostgresStorage) RemoveRule(ctx context.Context, domain string) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.ExecContext(ctx, `DELETE FROM extraction_rules WHERE domain = $1`, domain)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Правила всех доменов
+func (s *ExtractionRulePostgresStorage) Rules(ctx context.Context) ([]model.ExtractionRule, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var rules []dbExtractionRule
+	if err := conn.SelectContext(
+		ctx,
+		&rules,
+		`SELECT domain, content_selector, strip_selectors, paywall_selector, updated_at FROM extraction_rules ORDER BY domain`,
+	); err != nil {
+		return nil, err
+	}
+
+	return lo.Map(rules, func(rule dbExtractionRule, _ int) model.ExtractionRule { return rule.toModel() }), nil
+}
+
+type dbExtractionRule struct {
+	Domain          string         `db:"domain"`
+	ContentSelector string         `db:"content_selector"`
+	StripSelectors  pq.StringArray `db:"strip_selectors"`
+	PaywallSelector string         `db:"paywall_selector"`
+	UpdatedAt       time.Time      `db:"updated_at"`
+}
+
+func (r dbExtractionRule) toModel() model.ExtractionRule {
+	return model.ExtractionRule{
+		Domain:          r.Domain,
+		ContentSelector: r.ContentSelector,
+		StripSelectors:  r.StripSelectors,
+		PaywallSelector: r.PaywallSelector,
+		UpdatedAt:       r.UpdatedAt,
+	}
+}
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE article_reactions(
+    article_id INT NOT NULL,
+    user_id BIGINT NOT NULL,
+    reaction VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (article_id, user_id, reaction),
+    CONSTRAINT fk_article_reactions_article_id
+    FOREIGN KEY (article_id)
+        REFERENCES articles (id)
+        ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS article_reactions;
+-- +goose StatementEnd
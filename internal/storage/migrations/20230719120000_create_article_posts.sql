@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE articles
+    ADD COLUMN guid VARCHAR(255),
+    ADD COLUMN edit_pending BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN dropped_at TIMESTAMP;
+
+UPDATE articles SET guid = link WHERE guid IS NULL;
+
+CREATE INDEX idx_articles_source_id_guid ON articles (source_id, guid);
+
+CREATE TABLE article_posts(
+    article_id INT NOT NULL,
+    destination VARCHAR(64) NOT NULL,
+    chat_id BIGINT NOT NULL,
+    message_id INT NOT NULL,
+    posted_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (article_id, destination),
+    CONSTRAINT fk_article_posts_article_id
+    FOREIGN KEY (article_id)
+        REFERENCES articles (id)
+        ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS article_posts;
+
+DROP INDEX IF EXISTS idx_articles_source_id_guid;
+
+ALTER TABLE articles
+    DROP COLUMN IF EXISTS guid,
+    DROP COLUMN IF EXISTS edit_pending,
+    DROP COLUMN IF EXISTS dropped_at;
+-- +goose StatementEnd
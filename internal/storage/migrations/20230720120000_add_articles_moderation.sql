@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE articles
+    ADD COLUMN moderation_status VARCHAR(16),
+    ADD COLUMN moderation_message_id INT,
+    ADD COLUMN moderation_sent_at TIMESTAMP,
+    ADD COLUMN moderated_by BIGINT,
+    ADD COLUMN moderated_by_name VARCHAR(255),
+    ADD COLUMN moderated_at TIMESTAMP,
+    ADD COLUMN edited_summary TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE articles
+    DROP COLUMN IF EXISTS moderation_status,
+    DROP COLUMN IF EXISTS moderation_message_id,
+    DROP COLUMN IF EXISTS moderation_sent_at,
+    DROP COLUMN IF EXISTS moderated_by,
+    DROP COLUMN IF EXISTS moderated_by_name,
+    DROP COLUMN IF EXISTS moderated_at,
+    DROP COLUMN IF EXISTS edited_summary;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE article_posts RENAME TO deliveries;
+ALTER TABLE deliveries RENAME CONSTRAINT fk_article_posts_article_id TO fk_deliveries_article_id;
+ALTER TABLE deliveries RENAME COLUMN posted_at TO delivered_at;
+
+ALTER TABLE deliveries
+    ALTER COLUMN chat_id DROP NOT NULL,
+    ALTER COLUMN message_id DROP NOT NULL,
+    ALTER COLUMN delivered_at DROP NOT NULL,
+    ALTER COLUMN delivered_at DROP DEFAULT,
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'delivered',
+    ADD COLUMN attempts INT NOT NULL DEFAULT 1,
+    ADD COLUMN last_error TEXT,
+    ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM deliveries WHERE status <> 'delivered';
+
+ALTER TABLE deliveries
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS updated_at,
+    ALTER COLUMN chat_id SET NOT NULL,
+    ALTER COLUMN message_id SET NOT NULL,
+    ALTER COLUMN delivered_at SET NOT NULL,
+    ALTER COLUMN delivered_at SET DEFAULT NOW();
+
+ALTER TABLE deliveries RENAME COLUMN delivered_at TO posted_at;
+ALTER TABLE deliveries RENAME CONSTRAINT fk_deliveries_article_id TO fk_article_posts_article_id;
+ALTER TABLE deliveries RENAME TO article_posts;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE digest_recipients(
+    email VARCHAR(255) PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE digest_runs(
+    id SERIAL PRIMARY KEY,
+    sent_at TIMESTAMP NOT NULL,
+    articles_count INT NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS digest_runs;
+DROP TABLE IF EXISTS digest_recipients;
+-- +goose StatementEnd
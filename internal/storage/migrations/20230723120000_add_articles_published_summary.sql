@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE articles ADD COLUMN published_summary TEXT;
+CREATE INDEX articles_posted_at_idx ON articles (posted_at) WHERE posted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS articles_posted_at_idx;
+ALTER TABLE articles DROP COLUMN IF EXISTS published_summary;
+-- +goose StatementEnd
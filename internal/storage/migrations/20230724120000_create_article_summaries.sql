@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE article_summaries(
+    article_id INT PRIMARY KEY,
+    summary TEXT NOT NULL,
+    model VARCHAR(255) NOT NULL,
+    prompt_version VARCHAR(64) NOT NULL,
+    text_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_article_summaries_article_id
+    FOREIGN KEY (article_id)
+        REFERENCES articles (id)
+        ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS article_summaries;
+-- +goose StatementEnd
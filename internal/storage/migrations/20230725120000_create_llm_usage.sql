@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE llm_usage(
+    id SERIAL PRIMARY KEY,
+    article_id INT,
+    source_id INT,
+    model VARCHAR(255) NOT NULL,
+    prompt_tokens INT NOT NULL,
+    completion_tokens INT NOT NULL,
+    cost NUMERIC(12, 6) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_llm_usage_article_id
+    FOREIGN KEY (article_id)
+        REFERENCES articles (id)
+        ON DELETE SET NULL,
+    CONSTRAINT fk_llm_usage_source_id
+    FOREIGN KEY (source_id)
+        REFERENCES sources (id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX llm_usage_created_at_idx ON llm_usage (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS llm_usage;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE source_summary_settings(
+    source_id INT PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    prompt_template TEXT NOT NULL DEFAULT '',
+    max_length INT NOT NULL DEFAULT 0,
+    language VARCHAR(16) NOT NULL DEFAULT '',
+    CONSTRAINT fk_source_summary_settings_source_id
+    FOREIGN KEY (source_id)
+        REFERENCES sources (id)
+        ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS source_summary_settings;
+-- +goose StatementEnd
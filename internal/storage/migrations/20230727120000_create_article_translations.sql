@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE article_translations(
+    article_id INT NOT NULL,
+    language VARCHAR(16) NOT NULL,
+    title TEXT NOT NULL,
+    summary TEXT NOT NULL,
+    source_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (article_id, language),
+    CONSTRAINT fk_article_translations_article_id
+    FOREIGN KEY (article_id)
+        REFERENCES articles (id)
+        ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS article_translations;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE tags(
+    name VARCHAR(64) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE articles ADD COLUMN tags TEXT[];
+ALTER TABLE articles ADD COLUMN tagged_at TIMESTAMP;
+ALTER TABLE articles ADD COLUMN skipped_at TIMESTAMP;
+ALTER TABLE articles ADD COLUMN skip_reason TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE articles DROP COLUMN IF EXISTS skip_reason;
+ALTER TABLE articles DROP COLUMN IF EXISTS skipped_at;
+ALTER TABLE articles DROP COLUMN IF EXISTS tagged_at;
+ALTER TABLE articles DROP COLUMN IF EXISTS tags;
+DROP TABLE IF EXISTS tags;
+-- +goose StatementEnd
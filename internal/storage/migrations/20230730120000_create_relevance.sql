@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE editorial_briefs(
+    destination VARCHAR(64) PRIMARY KEY,
+    brief TEXT NOT NULL,
+    threshold INT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE article_scores(
+    article_id INT NOT NULL,
+    destination VARCHAR(64) NOT NULL,
+    score INT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    brief_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (article_id, destination),
+    CONSTRAINT fk_article_scores_article_id
+    FOREIGN KEY (article_id)
+        REFERENCES articles (id)
+        ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS article_scores;
+DROP TABLE IF EXISTS editorial_briefs;
+-- +goose StatementEnd
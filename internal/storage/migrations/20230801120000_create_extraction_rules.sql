@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE extraction_rules(
+    domain VARCHAR(255) PRIMARY KEY,
+    content_selector TEXT NOT NULL DEFAULT '',
+    strip_selectors TEXT[] NOT NULL DEFAULT '{}',
+    paywall_selector TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE articles ADD COLUMN paywalled BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE articles DROP COLUMN IF EXISTS paywalled;
+DROP TABLE IF EXISTS extraction_rules;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE article_translations ADD COLUMN tldr TEXT NOT NULL DEFAULT '';
+ALTER TABLE article_translations ADD COLUMN bullets TEXT[];
+ALTER TABLE article_translations ADD COLUMN entities TEXT[];
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE article_translations DROP COLUMN IF EXISTS entities;
+ALTER TABLE article_translations DROP COLUMN IF EXISTS bullets;
+ALTER TABLE article_translations DROP COLUMN IF EXISTS tldr;
+-- +goose StatementEnd
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Хранилище реакций пользователей на посты.
+// Храним каждую реакцию отдельно, чтобы один пользователь не мог накрутить счетчик
+type ReactionPostgresStorage struct {
+	db *sqlx.DB
+}
+
+func NewReactionStorage(db *sqlx.DB) *ReactionPostgresStorage {
+	return &ReactionPostgresStorage{db: db}
+}
+
+// Ставит реакцию пользователя на статью, а если она уже стоит - снимает ее
+func (s *ReactionPostgresStorage) Toggle(ctx context.Context, articleID int64, userID int64, reaction string) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.ExecContext(
+		ctx,
+		`DELETE FROM article_reactions WHERE article_id = $1 AND user_id = $2 AND reaction = $3`,
+		articleID,
+		userID,
+		reaction,
+	)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		return nil
+	}
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`INSERT INTO article_reactions (article_id, user_id, reaction)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`,
+		articleID,
+		userID,
+		reaction,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Возвращает количество каждой реакции на статью
+func (s *ReactionPostgresStorage) Counts(ctx context.Context, articleID int64) (map[string]int, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var rows []struct {
+		Reaction string `db:"reaction"`
+		Count    int    `db:"count"`
+	}
+	if err := conn.SelectContext(
+		ctx,
+		&rows,
+		`SELECT reaction, COUNT(*) AS count FROM article_reactions WHERE article_id = $1 GROUP BY reaction`,
+		articleID,
+	); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Reaction] = row.Count
+	}
+
+	return counts, nil
+}
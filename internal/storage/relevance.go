@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/samber/lo"
+)
+
+// Хранилище редакционных брифов мест назначения и оценок релевантности статей
+type RelevancePostgresStorage struct {
+	db *sqlx.DB
+}
+
+func NewRelevanceStorage(db *sqlx.DB) *RelevancePostgresStorage {
+	return &RelevancePostgresStorage{db: db}
+}
+
+// Сохраняет бриф места назначения, заменяя прежний
+func (s *RelevancePostgresStorage) SetBrief(ctx context.Context, brief model.Brief) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`INSERT INTO editorial_briefs (destination, brief, threshold, updated_at)
+		VALUES ($1, $2, $3, $4::timestamp)
+		ON CONFLICT (destination) DO UPDATE
+			SET brief = EXCLUDED.brief,
+				threshold = EXCLUDED.threshold,
+				updated_at = EXCLUDED.updated_at`,
+		brief.Destination,
+		brief.Text,
+		brief.Threshold,
+		time.Now().UTC().Format(time.RFC3339),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Удаляет бриф места назначения. Если брифа нет, возвращает sql.ErrNoRows
+func (s *RelevancePostgresStorage) RemoveBrief(ctx context.Context, destination string) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.ExecContext(ctx, `DELETE FROM editorial_briefs WHERE destination = $1`, destination)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Брифы всех мест назначения
+func (s *RelevancePostgresStorage) Briefs(ctx context.Context) ([]model.Brief, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var briefs []dbBrief
+	if err := conn.SelectContext(
+		ctx,
+		&briefs,
+		`SELECT destination, brief, threshold, updated_at FROM editorial_briefs ORDER BY destination`,
+	); err != nil {
+		return nil, err
+	}
+
+	return lo.Map(briefs, func(brief dbBrief, _ int) model.Brief { return model.Brief(brief) }), nil
+}
+
+// Оценка статьи для места назначения. Если статью еще не оценивали, возвращает nil
+func (s *RelevancePostgresStorage) Score(ctx context.Context, articleID int64, destination string) (*model.RelevanceScore, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var score dbScore
+	if err := conn.GetContext(
+		ctx,
+		&score,
+		`SELECT article_id, destination, score, reason, brief_hash, created_at FROM article_scores
+		WHERE article_id = $1 AND destination = $2`,
+		articleID,
+		destination,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	result := model.RelevanceScore(score)
+	return &result, nil
+}
+
+// Все оценки статьи по местам назначения
+func (s *RelevancePostgresStorage) Scores(ctx context.Context, articleID int64) ([]model.RelevanceScore, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var scores []dbScore
+	if err := conn.SelectContext(
+		ctx,
+		&scores,
+		`SELECT article_id, destination, score, reason, brief_hash, created_at FROM article_scores
+		WHERE article_id = $1 ORDER BY destination`,
+		articleID,
+	); err != nil {
+		return nil, err
+	}
+
+	return lo.Map(scores, func(score dbScore, _ int) model.RelevanceScore { return model.RelevanceScore(score) }), nil
+}
+
+// Сохраняет оценку статьи, заменяя прежнюю оценку для того же места назначения
+func (s *RelevancePostgresStorage) SaveScore(ctx context.Context, score model.RelevanceScore) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`INSERT INTO article_scores (article_id, destination, score, reason, brief_hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6::timestamp)
+		ON CONFLICT (article_id, destination) DO UPDATE
+			SET score = EXCLUDED.score,
+				reason = EXCLUDED.reason,
+				brief_hash = EXCLUDED.brief_hash,
+				created_at = EXCLUDED.created_at`,
+		score.ArticleID,
+		score.Destination,
+		score.Score,
+		score.Reason,
+		score.BriefHash,
+		time.Now().UTC().Format(time.RFC3339),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type dbBrief struct {
+	Destination string    `db:"destination"`
+	Text        string    `db:"brief"`
+	Threshold   int       `db:"threshold"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+type dbScore struct {
+	ArticleID   int64     `db:"article_id"`
+	Destination string    `db:"destination"`
+	Score       int       `db:"score"`
+	Reason      string    `db:"reason"`
+	BriefHash   string    `db:"brief_hash"`
+	CreatedAt   time.Time `db:"created_at"`
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/lib/pq"
+)
+
+// Хранилище сгенерированных summary. На каждую статью хранится последнее summary
+type SummaryPostgresStorage struct {
+	db *sqlx.DB
+}
+
+func NewSummaryStorage(db *sqlx.DB) *SummaryPostgresStorage {
+	return &SummaryPostgresStorage{db: db}
+}
+
+// Summary статьи. Если его еще не генерировали, возвращает nil
+func (s *SummaryPostgresStorage) SummaryByArticleID(ctx context.Context, articleID int64) (*model.Summary, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var summary dbSummary
+	if err := conn.GetContext(
+		ctx,
+		&summary,
+		`SELECT * FROM article_summaries WHERE article_id = $1`,
+		articleID,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	result := summary.toModel()
+	return &result, nil
+}
+
+// Сохраняет summary статьи, заменяя прежнее
+func (s *SummaryPostgresStorage) Save(ctx context.Context, summary model.Summary) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`INSERT INTO article_summaries (article_id, summary, tldr, bullets, entities, model, prompt_version, text_hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::timestamp)
+		ON CONFLICT (article_id) DO UPDATE
+			SET summary = EXCLUDED.summary,
+				tldr = EXCLUDED.tldr,
+				bullets = EXCLUDED.bullets,
+				entities = EXCLUDED.entities,
+				model = EXCLUDED.model,
+				prompt_version = EXCLUDED.prompt_version,
+				text_hash = EXCLUDED.text_hash,
+				created_at = EXCLUDED.created_at`,
+		summary.ArticleID,
+		summary.Text,
+		summary.TLDR,
+		pq.Array(summary.Bullets),
+		pq.Array(summary.Entities),
+		summary.Model,
+		summary.PromptVersion,
+		summary.TextHash,
+		time.Now().UTC().Format(time.RFC3339),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type dbSummary struct {
+	ArticleID     int64          `db:"article_id"`
+	Text          string         `db:"summary"`
+	TLDR          string         `db:"tldr"`
+	Bullets       pq.StringArray `db:"bullets"`
+	Entities      pq.StringArray `db:"entities"`
+	Model         string         `db:"model"`
+	PromptVersion string         `db:"prompt_version"`
+	TextHash      string         `db:"text_hash"`
+	CreatedAt     time.Time      `db:"created_at"`
+}
+
+func (s dbSummary) toModel() model.Summary {
+	return model.Summary{
+		ArticleID:     s.ArticleID,
+		Text:          s.Text,
+		TLDR:          s.TLDR,
+		Bullets:       s.Bullets,
+		Entities:      s.Entities,
+		Model:         s.Model,
+		PromptVersion: s.PromptVersion,
+		TextHash:      s.TextHash,
+		CreatedAt:     s.CreatedAt,
+	}
+}
+
+// Настройки генерации summary для источника. Если их не задавали, возвращаются настройки по умолчанию
+func (s *SummaryPostgresStorage) Settings(ctx context.Context, sourceID int64) (model.SummarySettings, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return model.SummarySettings{}, err
+	}
+	defer conn.Close()
+
+	var settings dbSummarySettings
+	if err := conn.GetContext(
+		ctx,
+		&settings,
+		`SELECT * FROM source_summary_settings WHERE source_id = $1`,
+		sourceID,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.SummarySettings{SourceID: sourceID, Enabled: true}, nil
+		}
+		return model.SummarySettings{}, err
+	}
+
+	return model.SummarySettings(settings), nil
+}
+
+// Сохраняет настройки генерации summary для источника
+func (s *SummaryPostgresStorage) SaveSettings(ctx context.Context, settings model.SummarySettings) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`INSERT INTO source_summary_settings (source_id, enabled, prompt_template, max_length, language)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (source_id) DO UPDATE
+			SET enabled = EXCLUDED.enabled,
+				prompt_template = EXCLUDED.prompt_template,
+				max_length = EXCLUDED.max_length,
+				language = EXCLUDED.language`,
+		settings.SourceID,
+		settings.Enabled,
+		settings.PromptTemplate,
+		settings.MaxLength,
+		settings.Language,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type dbSummarySettings struct {
+	SourceID       int64  `db:"source_id"`
+	Enabled        bool   `db:"enabled"`
+	PromptTemplate string `db:"prompt_template"`
+	MaxLength      int    `db:"max_length"`
+	Language       string `db:"language"`
+}
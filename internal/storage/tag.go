@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/samber/lo"
+)
+
+// Хранилище словаря тегов, из которого LLM выбирает теги для статей
+type TagPostgresStorage struct {
+	db *sqlx.DB
+}
+
+func NewTagStorage(db *sqlx.DB) *TagPostgresStorage {
+	return &TagPostgresStorage{db: db}
+}
+
+// Добавляет тег в словарь. Если тег уже есть, обновляет его описание
+func (s *TagPostgresStorage) AddTag(ctx context.Context, tag model.Tag) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`INSERT INTO tags (name, description) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description`,
+		tag.Name,
+		tag.Description,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Удаляет тег из словаря. Уже проставленные статьям теги остаются. Если такого тега нет, возвращает sql.ErrNoRows
+func (s *TagPostgresStorage) RemoveTag(ctx context.Context, name string) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.ExecContext(ctx, `DELETE FROM tags WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Весь словарь тегов по алфавиту
+func (s *TagPostgresStorage) Tags(ctx context.Context) ([]model.Tag, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var tags []dbTag
+	if err := conn.SelectContext(ctx, &tags, `SELECT name, description, created_at FROM tags ORDER BY name`); err != nil {
+		return nil, err
+	}
+
+	return lo.Map(tags, func(tag dbTag, _ int) model.Tag { return model.Tag(tag) }), nil
+}
+
+type dbTag struct {
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	CreatedAt   time.Time `db:"created_at"`
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/lib/pq"
+)
+
+// Хранилище переводов статей, чтобы не переводить статью заново при повторной отправке и редактировании
+type TranslationPostgresStorage struct {
+	db *sqlx.DB
+}
+
+func NewTranslationStorage(db *sqlx.DB) *TranslationPostgresStorage {
+	return &TranslationPostgresStorage{db: db}
+}
+
+// Перевод статьи на язык language. Если перевода нет, возвращает nil
+func (s *TranslationPostgresStorage) Translation(ctx context.Context, articleID int64, language string) (*model.Translation, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var translation dbTranslation
+	if err := conn.GetContext(
+		ctx,
+		&translation,
+		`SELECT article_id, language, title, summary, tldr, bullets, entities, source_hash FROM article_translations
+		WHERE article_id = $1 AND language = $2`,
+		articleID,
+		language,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	result := translation.toModel()
+	return &result, nil
+}
+
+// Сохраняет перевод статьи, заменяя прежний перевод на тот же язык
+func (s *TranslationPostgresStorage) SaveTranslation(ctx context.Context, translation model.Translation) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`INSERT INTO article_translations (article_id, language, title, summary, tldr, bullets, entities, source_hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (article_id, language) DO UPDATE
+			SET title = EXCLUDED.title,
+				summary = EXCLUDED.summary,
+				tldr = EXCLUDED.tldr,
+				bullets = EXCLUDED.bullets,
+				entities = EXCLUDED.entities,
+				source_hash = EXCLUDED.source_hash,
+				created_at = NOW()`,
+		translation.ArticleID,
+		translation.Language,
+		translation.Title,
+		translation.Summary,
+		translation.TLDR,
+		pq.Array(translation.Bullets),
+		pq.Array(translation.Entities),
+		translation.SourceHash,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type dbTranslation struct {
+	ArticleID  int64          `db:"article_id"`
+	Language   string         `db:"language"`
+	Title      string         `db:"title"`
+	Summary    string         `db:"summary"`
+	TLDR       string         `db:"tldr"`
+	Bullets    pq.StringArray `db:"bullets"`
+	Entities   pq.StringArray `db:"entities"`
+	SourceHash string         `db:"source_hash"`
+}
+
+func (t dbTranslation) toModel() model.Translation {
+	return model.Translation{
+		ArticleID:  t.ArticleID,
+		Language:   t.Language,
+		Title:      t.Title,
+		Summary:    t.Summary,
+		TLDR:       t.TLDR,
+		Bullets:    t.Bullets,
+		Entities:   t.Entities,
+		SourceHash: t.SourceHash,
+	}
+}
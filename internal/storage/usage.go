@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/samber/lo"
+)
+
+// Хранилище расхода токенов LLM
+type UsagePostgresStorage struct {
+	db *sqlx.DB
+}
+
+func NewUsageStorage(db *sqlx.DB) *UsagePostgresStorage {
+	return &UsagePostgresStorage{db: db}
+}
+
+// Сохраняет расход токенов на один запрос
+func (s *UsagePostgresStorage) Record(ctx context.Context, usage model.Usage) error {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(
+		ctx,
+		`INSERT INTO llm_usage (article_id, source_id, model, prompt_tokens, completion_tokens, cost, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::timestamp)`,
+		sql.NullInt64{Int64: usage.ArticleID, Valid: usage.ArticleID != 0},
+		sql.NullInt64{Int64: usage.SourceID, Valid: usage.SourceID != 0},
+		usage.Model,
+		usage.PromptTokens,
+		usage.CompletionTokens,
+		usage.Cost,
+		time.Now().UTC().Format(time.RFC3339),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Сколько потрачено с момента since
+func (s *UsagePostgresStorage) Spent(ctx context.Context, since time.Time) (float64, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var spent float64
+	if err := conn.GetContext(
+		ctx,
+		&spent,
+		`SELECT COALESCE(SUM(cost), 0) FROM llm_usage WHERE created_at >= $1::timestamp`,
+		since.UTC().Format(time.RFC3339),
+	); err != nil {
+		return 0, err
+	}
+
+	return spent, nil
+}
+
+// Расход по дням начиная с since, от новых дней к старым
+func (s *UsagePostgresStorage) UsageByDay(ctx context.Context, since time.Time) ([]model.UsageTotal, error) {
+	return s.totals(
+		ctx,
+		`SELECT TO_CHAR(created_at, 'YYYY-MM-DD') AS key,
+				COUNT(*) AS requests,
+				SUM(prompt_tokens) AS prompt_tokens,
+				SUM(completion_tokens) AS completion_tokens,
+				SUM(cost) AS cost
+			FROM llm_usage
+			WHERE created_at >= $1::timestamp
+			GROUP BY key
+			ORDER BY key DESC`,
+		since,
+	)
+}
+
+// Расход по источникам начиная с since, от самых дорогих к дешевым
+func (s *UsagePostgresStorage) UsageBySource(ctx context.Context, since time.Time) ([]model.UsageTotal, error) {
+	return s.totals(
+		ctx,
+		`SELECT COALESCE(s.name, 'без источника') AS key,
+				COUNT(*) AS requests,
+				SUM(u.prompt_tokens) AS prompt_tokens,
+				SUM(u.completion_tokens) AS completion_tokens,
+				SUM(u.cost) AS cost
+			FROM llm_usage u
+			LEFT JOIN sources s ON s.id = u.source_id
+			WHERE u.created_at >= $1::timestamp
+			GROUP BY key
+			ORDER BY cost DESC`,
+		since,
+	)
+}
+
+func (s *UsagePostgresStorage) totals(ctx context.Context, query string, since time.Time) ([]model.UsageTotal, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var totals []dbUsageTotal
+	if err := conn.SelectContext(ctx, &totals, query, since.UTC().Format(time.RFC3339)); err != nil {
+		return nil, err
+	}
+
+	return lo.Map(totals, func(total dbUsageTotal, _ int) model.UsageTotal {
+		return model.UsageTotal(total)
+	}), nil
+}
+
+type dbUsageTotal struct {
+	Key              string  `db:"key"`
+	Requests         int     `db:"requests"`
+	PromptTokens     int     `db:"prompt_tokens"`
+	CompletionTokens int     `db:"completion_tokens"`
+	Cost             float64 `db:"cost"`
+}
@@ -0,0 +1,93 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Ошибка, которую возвращает CircuitBreaker, пока API не вызывается после серии ошибок
+var ErrCircuitOpen = errors.New("llm circuit is open")
+
+// Circuit breaker для запросов к LLM. Через него проходят все запросы: summary, перевод, теги и оценка релевантности,
+// поэтому во время сбоя API ни один из них не ждет таймаута.
+// После threshold ошибок подряд перестает вызывать API на cooldown и сразу возвращает ErrCircuitOpen. Нулевой threshold выключает breaker. По истечении cooldown пропускает один пробный запрос:
+// если он успешен, работа восстанавливается, иначе пауза начинается заново
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu sync.Mutex
+	// Число ошибок подряд
+	failures int
+	// До какого момента вызовы запрещены
+	openUntil time.Time
+	// Идет пробный запрос после паузы
+	probing bool
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// Выполняет запрос call, если API сейчас не на паузе, и учитывает его результат
+func (b *CircuitBreaker) Call(ctx context.Context, call func() error) error {
+	ok, probe := b.allow()
+	if !ok {
+		return ErrCircuitOpen
+	}
+
+	err := call()
+	b.done(ctx, err, probe)
+
+	return err
+}
+
+// Можно ли выполнить запрос и является ли он пробным запросом после паузы
+func (b *CircuitBreaker) allow() (ok bool, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Нулевой порог выключает circuit breaker
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return true, false
+	}
+
+	// Пауза еще идет или пробный запрос уже отправлен другим вызовом
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false, false
+	}
+
+	b.probing = true
+	return true, true
+}
+
+// Учитывает результат запроса. Флаг пробного запроса снимает только сам пробный запрос:
+// запросы, начатые до паузы, могут завершиться, пока он еще идет
+func (b *CircuitBreaker) done(ctx context.Context, err error, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+
+	// Исчерпанный бюджет и отмена контекста не говорят о проблемах с API
+	if err == nil || errors.Is(err, ErrBudgetExceeded) || ctx.Err() != nil {
+		if err == nil {
+			b.failures = 0
+		}
+		return
+	}
+
+	b.failures++
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+		log.Printf("[WARN] llm failed %d times in a row, pausing calls until %s: %v", b.failures, b.openUntil.Format(time.RFC3339), err)
+	}
+}
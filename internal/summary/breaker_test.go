@@ -0,0 +1,47 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	b := NewCircuitBreaker(1, time.Millisecond)
+	ctx := context.Background()
+	apiErr := errors.New("model is overloaded")
+
+	// Запрос начат до паузы и завершится, пока идет пробный запрос
+	ok, slowProbe := b.allow()
+	if !ok || slowProbe {
+		t.Fatalf("allow() = %v, %v before failures, want true, false", ok, slowProbe)
+	}
+
+	if err := b.Call(ctx, func() error { return apiErr }); !errors.Is(err, apiErr) {
+		t.Fatalf("Call() error = %v, want %v", err, apiErr)
+	}
+	if err := b.Call(ctx, func() error { return nil }); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Call() error = %v during cooldown, want ErrCircuitOpen", err)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	ok, probe := b.allow()
+	if !ok || !probe {
+		t.Fatalf("allow() = %v, %v after cooldown, want a probe", ok, probe)
+	}
+
+	b.done(ctx, ErrBudgetExceeded, slowProbe)
+
+	// Завершение обычного запроса не должно пропускать второй пробный запрос
+	if ok, _ := b.allow(); ok {
+		t.Fatal("allow() = true while the probe is in flight")
+	}
+
+	b.done(ctx, nil, probe)
+
+	if err := b.Call(ctx, func() error { return nil }); err != nil {
+		t.Fatalf("Call() error = %v after a successful probe", err)
+	}
+}
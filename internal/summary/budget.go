@@ -0,0 +1,124 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ошибка, которую возвращает LLM summarizer, когда дневной или месячный бюджет исчерпан.
+// FallbackSummarizer в этом случае переключается на запасной summarizer
+var ErrBudgetExceeded = errors.New("llm budget exceeded")
+
+// Цена модели в долларах за 1000 токенов
+type Price struct {
+	Prompt     float64
+	Completion float64
+}
+
+// Таблица цен по именам моделей
+type Prices map[string]Price
+
+// Разбирает таблицу цен из строк вида "модель:цена промпта:цена ответа", цены в долларах за 1000 токенов
+func ParsePrices(lines []string) (Prices, error) {
+	prices := make(Prices, len(lines))
+
+	for _, line := range lines {
+		// Имя модели может содержать двоеточие, поэтому цены берем с конца
+		parts := strings.Split(line, ":")
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("invalid price %q, expected model:prompt:completion", line)
+		}
+
+		name := strings.Join(parts[:len(parts)-2], ":")
+
+		prompt, err := strconv.ParseFloat(parts[len(parts)-2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt price in %q: %w", line, err)
+		}
+
+		completion, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid completion price in %q: %w", line, err)
+		}
+
+		prices[name] = Price{Prompt: prompt, Completion: completion}
+	}
+
+	return prices, nil
+}
+
+// Оценка стоимости запроса. Для моделей, которых нет в таблице, например self-hosted, стоимость нулевая
+func (p Prices) Cost(model string, promptTokens int, completionTokens int) float64 {
+	price, ok := p[model]
+	if !ok {
+		return 0
+	}
+
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1000
+}
+
+type UsageStorage interface {
+	Record(ctx context.Context, usage model.Usage) error
+	Spent(ctx context.Context, since time.Time) (float64, error)
+}
+
+// Учет расхода токенов и ограничение трат на LLM.
+// Нулевой лимит означает, что ограничения нет
+type Budget struct {
+	storage UsageStorage
+	prices  Prices
+	// Лимиты трат в долларах на календарный день и месяц по UTC
+	daily   float64
+	monthly float64
+}
+
+func NewBudget(storage UsageStorage, prices Prices, daily float64, monthly float64) *Budget {
+	return &Budget{
+		storage: storage,
+		prices:  prices,
+		daily:   daily,
+		monthly: monthly,
+	}
+}
+
+// Проверяет, что дневной и месячный лимиты еще не исчерпаны
+func (b *Budget) Check(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	limits := []struct {
+		name  string
+		limit float64
+		since time.Time
+	}{
+		{name: "daily", limit: b.daily, since: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)},
+		{name: "monthly", limit: b.monthly, since: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, limit := range limits {
+		if limit.limit <= 0 {
+			continue
+		}
+
+		spent, err := b.storage.Spent(ctx, limit.since)
+		if err != nil {
+			return fmt.Errorf("get %s spend: %w", limit.name, err)
+		}
+
+		if spent >= limit.limit {
+			return fmt.Errorf("%w: %s spend $%.2f of $%.2f", ErrBudgetExceeded, limit.name, spent, limit.limit)
+		}
+	}
+
+	return nil
+}
+
+// Сохраняет расход токенов на запрос вместе с оценкой его стоимости
+func (b *Budget) Record(ctx context.Context, usage model.Usage) error {
+	usage.Cost = b.prices.Cost(usage.Model, usage.PromptTokens, usage.CompletionTokens)
+	return b.storage.Record(ctx, usage)
+}
@@ -0,0 +1,111 @@
+package summary
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Оценка числа токенов в тексте без токенизатора модели.
+// Латиница в среднем занимает около 4 символов на токен, кириллица и прочие символы - около 2,
+// поэтому оценка получается с запасом для обоих языков
+func estimateTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+
+	return ascii/4 + other/2 + 1
+}
+
+// Обрезает текст так, чтобы он укладывался в maxTokens. Режем по границе предложения, если она есть
+func truncateTokens(text string, maxTokens int) string {
+	if maxTokens <= 0 || estimateTokens(text) <= maxTokens {
+		return text
+	}
+
+	var b strings.Builder
+	for _, sentence := range splitSentences(text) {
+		if estimateTokens(b.String()+sentence) > maxTokens {
+			break
+		}
+
+		if b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(sentence)
+	}
+
+	// Первое же предложение не влезает, режем его по символам
+	if b.Len() == 0 {
+		return splitLong(text, maxTokens)[0]
+	}
+
+	return b.String()
+}
+
+// Делит текст на куски не больше maxTokens каждый. Предложения не разрываются,
+// если только одно предложение само по себе не длиннее maxTokens
+func chunkText(text string, maxTokens int) []string {
+	var (
+		chunks  []string
+		current strings.Builder
+	)
+
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, sentence := range splitSentences(text) {
+		if estimateTokens(sentence) > maxTokens {
+			flush()
+			chunks = append(chunks, splitLong(sentence, maxTokens)...)
+			continue
+		}
+
+		if current.Len() > 0 && estimateTokens(current.String()+" "+sentence) > maxTokens {
+			flush()
+		}
+
+		if current.Len() > 0 {
+			current.WriteString(" ")
+		}
+		current.WriteString(sentence)
+	}
+	flush()
+
+	return chunks
+}
+
+// Режет слишком длинный текст на части по символам, стараясь попадать на пробелы
+func splitLong(text string, maxTokens int) []string {
+	var parts []string
+
+	runes := []rune(text)
+	for len(runes) > 0 {
+		// Худший случай - 2 символа на токен
+		end := maxTokens * 2
+		if end >= len(runes) {
+			parts = append(parts, string(runes))
+			break
+		}
+
+		for cut := end; cut > end/2; cut-- {
+			if runes[cut] == ' ' {
+				end = cut
+				break
+			}
+		}
+
+		parts = append(parts, strings.TrimSpace(string(runes[:end])))
+		runes = runes[end:]
+	}
+
+	return parts
+}
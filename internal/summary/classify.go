@@ -0,0 +1,86 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"strings"
+)
+
+const classifyPromt = `Выбери не больше %d тегов, которые лучше всего описывают статью. Используй только теги из списка.
+Ответь только JSON массивом названий тегов без пояснений, например ["go", "databases"]. Если ни один тег не подходит, ответь [].
+Теги:
+%s`
+
+// Для выбора тегов хватает начала статьи
+const classifyInputTokens = 1500
+
+// Классификация статей по словарю тегов через тот же LLM бэкенд, что генерирует summary
+type OpenAIClassifier struct {
+	llm     *OpenAISummarizer
+	maxTags int
+}
+
+func NewOpenAIClassifier(llm *OpenAISummarizer, maxTags int) *OpenAIClassifier {
+	return &OpenAIClassifier{llm: llm, maxTags: maxTags}
+}
+
+// Возвращает до maxTags тегов из словаря. Теги, которых нет в словаре, отбрасываются
+func (c *OpenAIClassifier) Classify(ctx context.Context, req model.ClassificationRequest) ([]string, error) {
+	if len(req.Vocabulary) == 0 {
+		return nil, nil
+	}
+
+	release, err := c.llm.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Ответ приводим к названиям из словаря, модель может поменять регистр
+	vocabulary := make(map[string]string, len(req.Vocabulary))
+
+	var list strings.Builder
+	for _, tag := range req.Vocabulary {
+		vocabulary[strings.ToLower(tag.Name)] = tag.Name
+
+		list.WriteString("- " + tag.Name)
+		if tag.Description != "" {
+			list.WriteString(": " + tag.Description)
+		}
+		list.WriteString("\n")
+	}
+
+	system := fmt.Sprintf(classifyPromt, c.maxTags, strings.TrimSpace(list.String()))
+	user := req.Title + "\n\n" + truncateTokens(req.Text, classifyInputTokens)
+
+	choice, err := c.llm.chat(ctx, req.ArticleID, req.SourceID, system, user, 16*c.maxTags+16)
+	if err != nil {
+		return nil, err
+	}
+
+	var answer []string
+	if err := json.Unmarshal([]byte(trimCodeFence(choice.Message.Content)), &answer); err != nil {
+		return nil, fmt.Errorf("parse tags: %w", err)
+	}
+
+	var tags []string
+	seen := make(map[string]bool)
+
+	for _, name := range answer {
+		tag, ok := vocabulary[strings.ToLower(strings.TrimSpace(name))]
+		if !ok || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+
+		if len(tags) == c.maxTags {
+			break
+		}
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,40 @@
+package summary
+
+import (
+	"context"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"log"
+)
+
+type Summarizer interface {
+	Summarize(ctx context.Context, req model.SummaryRequest) (model.Summary, error)
+}
+
+// Summarizer с запасным вариантом. Если основной summarizer выключен и вернул пустую строку
+// или завершился ошибкой (недоступен, превышен бюджет), summary генерирует запасной
+type FallbackSummarizer struct {
+	primary  Summarizer
+	fallback Summarizer
+}
+
+func NewFallbackSummarizer(primary Summarizer, fallback Summarizer) *FallbackSummarizer {
+	return &FallbackSummarizer{primary: primary, fallback: fallback}
+}
+
+func (s *FallbackSummarizer) Summarize(ctx context.Context, req model.SummaryRequest) (model.Summary, error) {
+	summary, err := s.primary.Summarize(ctx, req)
+	if err == nil && summary.Text != "" {
+		return summary, nil
+	}
+
+	// Если нас остановили, запасной summary не нужен
+	if ctx.Err() != nil {
+		return model.Summary{}, ctx.Err()
+	}
+
+	if err != nil {
+		log.Printf("[WARN] summarizer failed, using fallback: %v", err)
+	}
+
+	return s.fallback.Summarize(ctx, req)
+}
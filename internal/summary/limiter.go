@@ -0,0 +1,111 @@
+package summary
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Окно, за которое считаются лимиты запросов и токенов
+const limitWindow = time.Minute
+
+// Ограничение нагрузки на LLM API: число одновременных запросов,
+// а также запросов и токенов в минуту (RPM и TPM). Нулевой лимит означает, что ограничения нет
+type Limiter struct {
+	// Семафор одновременных запросов
+	slots chan struct{}
+	rpm   int
+	tpm   int
+
+	mu sync.Mutex
+	// Запросы за последнюю минуту в порядке отправки
+	window []limitEntry
+}
+
+type limitEntry struct {
+	at     time.Time
+	tokens int
+}
+
+func NewLimiter(concurrency int, rpm int, tpm int) *Limiter {
+	l := &Limiter{rpm: rpm, tpm: tpm}
+
+	if concurrency > 0 {
+		l.slots = make(chan struct{}, concurrency)
+	}
+
+	return l
+}
+
+// Занимает слот для запроса, ждет, если все слоты заняты. После запроса слот нужно вернуть через Release
+func (l *Limiter) Acquire(ctx context.Context) error {
+	if l.slots == nil {
+		return nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *Limiter) Release() {
+	if l.slots == nil {
+		return
+	}
+
+	<-l.slots
+}
+
+// Ждет, пока запрос на tokens токенов уложится в лимиты RPM и TPM, и учитывает его.
+// Запрос больше всего лимита TPM пропускается, когда за последнюю минуту не было других запросов
+func (l *Limiter) Wait(ctx context.Context, tokens int) error {
+	for {
+		wait := l.reserve(tokens)
+		if wait == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Учитывает запрос, если он укладывается в лимиты, и возвращает 0.
+// Иначе возвращает, сколько ждать до следующей проверки
+func (l *Limiter) reserve(tokens int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	// Выкидываем запросы, которые вышли из окна
+	expired := 0
+	for expired < len(l.window) && now.Sub(l.window[expired].at) >= limitWindow {
+		expired++
+	}
+	l.window = l.window[expired:]
+
+	used := 0
+	for _, entry := range l.window {
+		used += entry.tokens
+	}
+
+	fitsRPM := l.rpm <= 0 || len(l.window) < l.rpm
+	fitsTPM := l.tpm <= 0 || used+tokens <= l.tpm || len(l.window) == 0
+
+	if fitsRPM && fitsTPM {
+		l.window = append(l.window, limitEntry{at: now, tokens: tokens})
+		return 0
+	}
+
+	// Ждем, пока из окна выйдет самый старый запрос
+	return l.window[0].at.Add(limitWindow).Sub(now)
+}
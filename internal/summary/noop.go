@@ -0,0 +1,18 @@
+package summary
+
+import (
+	"context"
+
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+)
+
+// Summarizer, который ничего не генерирует. Посты уходят без summary
+type NoopSummarizer struct{}
+
+func NewNoopSummarizer() *NoopSummarizer {
+	return &NoopSummarizer{}
+}
+
+func (s *NoopSummarizer) Summarize(_ context.Context, _ model.SummaryRequest) (model.Summary, error) {
+	return model.Summary{}, nil
+}
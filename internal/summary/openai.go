@@ -2,76 +2,276 @@ package summary
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
 	"github.com/sashabaranov/go-openai"
 	"log"
 	"strings"
-	"sync"
 )
 
-// Имплементация интерфейса summarizer, который уже ранее был объявлен
+type UsageTracker interface {
+	Check(ctx context.Context) error
+	Record(ctx context.Context, usage model.Usage) error
+}
+
+// Ограничение нагрузки на API: одновременные запросы, запросы и токены в минуту
+type RateLimiter interface {
+	Acquire(ctx context.Context) error
+	Release()
+	Wait(ctx context.Context, tokens int) error
+}
+
+// Имплементация интерфейса summarizer, который уже ранее был объявлен.
+// Работает как с OpenAI, так и с self-hosted серверами с OpenAI-совместимым API
 type OpenAISummarizer struct {
 	// sdk для openai
 	client *openai.Client
 	// С его помощью будем просить gpt генерить summary
 	promt string
+	// Просить ли summary в виде JSON с TL;DR, ключевыми фактами и упомянутыми сущностями
+	structured bool
+	// Модель и параметры генерации
+	model       string
+	temperature float32
+	maxTokens   int
+	// Сколько токенов статьи отправляется в одном запросе и сколько токенов статьи учитывается вообще
+	chunkTokens    int
+	maxInputTokens int
+	// Учет расхода токенов и лимиты трат
+	budget UsageTracker
+	// Лимиты нагрузки на API
+	limiter RateLimiter
+	// Пауза в запросах к API после серии ошибок
+	breaker *CircuitBreaker
 	// Флаг вкл/выкл summarizer
 	enabled bool
-	mu      sync.Mutex
 }
 
-func NewOpenAISummarizer(apiKey string, promt string) *OpenAISummarizer {
+// Пустой baseURL означает стандартный адрес OpenAI.
+// Self-hosted серверы часто работают без ключа, поэтому summarizer включен, если задан ключ или свой адрес
+func NewOpenAISummarizer(
+	apiKey string,
+	baseURL string,
+	model string,
+	temperature float32,
+	maxTokens int,
+	chunkTokens int,
+	maxInputTokens int,
+	promt string,
+	structured bool,
+	budget UsageTracker,
+	limiter RateLimiter,
+	breaker *CircuitBreaker,
+) *OpenAISummarizer {
+	clientConfig := openai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		clientConfig.BaseURL = baseURL
+	}
+
 	s := &OpenAISummarizer{
-		client: openai.NewClient(apiKey),
-		promt:  promt,
+		client:         openai.NewClientWithConfig(clientConfig),
+		promt:          promt,
+		structured:     structured,
+		model:          model,
+		temperature:    temperature,
+		maxTokens:      maxTokens,
+		chunkTokens:    chunkTokens,
+		maxInputTokens: maxInputTokens,
+		budget:         budget,
+		limiter:        limiter,
+		breaker:        breaker,
 	}
 
-	log.Printf("openai summarizer enabled: %v", apiKey != "")
+	s.enabled = apiKey != "" || baseURL != ""
 
-	if apiKey != "" {
-		s.enabled = true
-	}
+	log.Printf("openai summarizer enabled: %v, base url: %s, model: %s", s.enabled, clientConfig.BaseURL, model)
 
 	return s
 }
 
-func (s *OpenAISummarizer) Summarize(ctx context.Context, text string) (string, error) {
-	// Обкладываем мьютексами, т.к. конкурентный доступ может вызывать сюрпризы
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// Инструкция для отдельных кусков длинной статьи. Итоговое summary потом собирается из пересказов кусков
+const chunkPromt = "Это фрагмент длинной статьи. Кратко перескажи его основные факты, они будут объединены с пересказами других фрагментов."
+
+// Сколько раз можно пересказывать пересказы. Если они так и не поместились в один запрос, лишнее отрезается
+const maxReduceRounds = 3
 
+// Генерирует summary текста по промпту promt, пустой promt означает промпт из конфига. Текст длиннее maxInputTokens обрезается.
+// Если текст не помещается в один запрос, он делится на куски по chunkTokens:
+// сначала пересказывается каждый кусок, затем пересказы объединяются в одно summary (map-reduce)
+func (s *OpenAISummarizer) Summarize(ctx context.Context, req model.SummaryRequest) (model.Summary, error) {
 	if !s.enabled {
-		return "", nil
+		return model.Summary{}, nil
 	}
 
+	// Если бюджет исчерпан, не тратим деньги, а отдаем ошибку: дальше сработает запасной summarizer.
+	// Куски одной статьи пересказываем последовательно, а разные статьи - параллельно в пределах лимита
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return model.Summary{}, err
+	}
+	defer release()
+
+	promt := req.Promt
+	if promt == "" {
+		promt = s.promt
+	}
+
+	if constraints := promptConstraints(req.InputLanguage, req.Language, req.MaxLength); constraints != "" {
+		promt = strings.TrimSpace(promt + "\n" + constraints)
+	}
+
+	text := truncateTokens(req.Text, s.maxInputTokens)
+
+	for round := 0; s.chunkTokens > 0 && estimateTokens(text) > s.chunkTokens; round++ {
+		if round == maxReduceRounds {
+			text = truncateTokens(text, s.chunkTokens)
+			break
+		}
+
+		chunks := chunkText(text, s.chunkTokens)
+
+		partials := make([]string, 0, len(chunks))
+		for _, chunk := range chunks {
+			partial, err := s.complete(ctx, req, promt+"\n"+chunkPromt, chunk)
+			if err != nil {
+				return model.Summary{}, fmt.Errorf("summarize chunk: %w", err)
+			}
+
+			partials = append(partials, partial)
+		}
+
+		// Если пересказы вместе все еще не помещаются в запрос, повторяем еще раз уже над ними
+		text = strings.Join(partials, "\n")
+	}
+
+	if s.structured {
+		return s.completeStructured(ctx, req, promt, text)
+	}
+
+	summary, err := s.complete(ctx, req, promt, text)
+	if err != nil {
+		return model.Summary{}, err
+	}
+
+	// Модель не всегда соблюдает ограничение длины из промпта, поэтому обрезаем ответ сами
+	return model.Summary{
+		Text:          limitLength(summary, req.MaxLength),
+		Model:         s.model,
+		PromptVersion: PromptVersion(promt),
+	}, nil
+}
+
+// Версия промпта - начало sha256 его текста. Меняется при любом изменении промпта
+func PromptVersion(promt string) string {
+	sum := sha256.Sum256([]byte(promt))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Отправляет один запрос на summary. Инструкция передается системным сообщением, а текст статьи - пользовательским
+func (s *OpenAISummarizer) complete(ctx context.Context, req model.SummaryRequest, promt string, text string) (string, error) {
+	choice, err := s.chat(ctx, req.ArticleID, req.SourceID, promt, text, s.maxTokens)
+	if err != nil {
+		return "", err
+	}
+
+	rawSummary := strings.TrimSpace(choice.Message.Content)
+	if choice.FinishReason != openai.FinishReasonLength {
+		return rawSummary, nil
+	}
+
+	// Модель уперлась в лимит токенов на ответ и оборвала текст на полуслове.
+	// Оставляем только законченные предложения, а если их нет, возвращаем как есть
+	log.Printf("[WARN] summary was cut off by max tokens limit %d", s.maxTokens)
+
+	sentences := splitSentences(rawSummary)
+	if len(sentences) <= 1 {
+		return rawSummary, nil
+	}
+
+	return strings.Join(sentences[:len(sentences)-1], " "), nil
+}
+
+// Проверяет бюджет и занимает место среди одновременных запросов к API для одной операции: summary, перевода и т.д.
+// По окончании операции нужно вызвать release
+func (s *OpenAISummarizer) acquire(ctx context.Context) (func(), error) {
+	if err := s.budget.Check(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := s.limiter.Acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.limiter.Release, nil
+}
+
+// Отправляет один запрос к модели с учетом лимитов и учитывает потраченные токены.
+// Возвращает первый из вариантов ответа
+func (s *OpenAISummarizer) chat(
+	ctx context.Context,
+	articleID int64,
+	sourceID int64,
+	system string,
+	user string,
+	maxTokens int,
+) (openai.ChatCompletionChoice, error) {
 	// Составляем запрос к openai
 	request := openai.ChatCompletionRequest{
-		Model: "gpt-3.5-turbo",
+		Model: s.model,
 		Messages: []openai.ChatCompletionMessage{
 			{
 				Role:    openai.ChatMessageRoleSystem,
-				Content: fmt.Sprintf("%s%s", text, s.promt),
+				Content: system,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: user,
 			},
 		},
-		MaxTokens:   256,
-		Temperature: 0.7,
+		MaxTokens:   maxTokens,
+		Temperature: s.temperature,
 		TopP:        1,
 	}
 
-	// Отправляем запрос
-	resp, err := s.client.CreateChatCompletion(ctx, request)
-	if err != nil {
-		return "", err
+	// Отправляем запрос. Во время сбоя API breaker сразу возвращает ErrCircuitOpen, не дожидаясь лимитов и таймаутов
+	var resp openai.ChatCompletionResponse
+	if err := s.breaker.Call(ctx, func() error {
+		// Для лимита TPM считаем и промпт, и максимально возможный ответ
+		if err := s.limiter.Wait(ctx, estimateTokens(system)+estimateTokens(user)+maxTokens); err != nil {
+			return err
+		}
+
+		var err error
+		resp, err = s.client.CreateChatCompletion(ctx, request)
+		return err
+	}); err != nil {
+		return openai.ChatCompletionChoice{}, err
 	}
 
-	// openai отправляем нам несколько вариантов, мы выбираем самый первый
-	rawSummary := strings.TrimSpace(resp.Choices[0].Message.Content)
-	if strings.HasSuffix(rawSummary, ".") {
-		return rawSummary, nil
+	// Ошибка учета не должна ломать генерацию, за которую уже заплачено
+	if err := s.budget.Record(ctx, model.Usage{
+		ArticleID:        articleID,
+		SourceID:         sourceID,
+		Model:            s.model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}); err != nil {
+		log.Printf("[ERROR] failed to record llm usage of article %d: %v", articleID, err)
 	}
 
-	sentences := strings.Split(rawSummary, ".")
+	// Совместимые серверы могут вернуть пустой ответ, например при ошибке модели
+	if len(resp.Choices) == 0 {
+		return openai.ChatCompletionChoice{}, errors.New("empty completion response")
+	}
+
+	// openai отправляем нам несколько вариантов, мы выбираем самый первый
+	return resp.Choices[0], nil
+}
 
-	// Берем все предложения кроме последнего. добавляем между ними точку. и к последнему предложению добавляем точку.
-	return strings.Join(sentences[:len(sentences)-1], ".") + ".", nil
+// Включен ли summarizer, то есть задан ли ключ или свой адрес API
+func (s *OpenAISummarizer) Enabled() bool {
+	return s.enabled
 }
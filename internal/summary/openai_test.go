@@ -0,0 +1,209 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"github.com/sashabaranov/go-openai"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Заглушка OpenAI-совместимого сервера, как у self-hosted бэкендов. Отвечает заданным текстом и запоминает запросы
+type stubLLM struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	requests []openai.ChatCompletionRequest
+	headers  []http.Header
+}
+
+func newStubLLM(t *testing.T, status int, content string, finishReason openai.FinishReason) *stubLLM {
+	t.Helper()
+
+	stub := &stubLLM{}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+
+		stub.mu.Lock()
+		stub.requests = append(stub.requests, req)
+		stub.headers = append(stub.headers, r.Header.Clone())
+		stub.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{"message": "model is overloaded", "type": "server_error"},
+			})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Model: req.Model,
+			Choices: []openai.ChatCompletionChoice{{
+				Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+				FinishReason: finishReason,
+			}},
+			Usage: openai.Usage{PromptTokens: 120, CompletionTokens: 30, TotalTokens: 150},
+		})
+	}))
+	t.Cleanup(stub.server.Close)
+
+	return stub
+}
+
+func (s *stubLLM) calls() []openai.ChatCompletionRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]openai.ChatCompletionRequest(nil), s.requests...)
+}
+
+type fakeUsage struct {
+	mu    sync.Mutex
+	usage []model.Usage
+}
+
+func (f *fakeUsage) Check(context.Context) error {
+	return nil
+}
+
+func (f *fakeUsage) Record(_ context.Context, usage model.Usage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.usage = append(f.usage, usage)
+	return nil
+}
+
+func newTestSummarizer(apiKey string, baseURL string, usage *fakeUsage) *OpenAISummarizer {
+	return NewOpenAISummarizer(
+		apiKey,
+		baseURL,
+		"llama-3-8b-instruct",
+		0.2,
+		128,
+		0,
+		0,
+		"Сделай краткое содержание",
+		false,
+		usage,
+		NewLimiter(0, 0, 0),
+		NewCircuitBreaker(0, 0),
+	)
+}
+
+func TestOpenAISummarizerSelfHosted(t *testing.T) {
+	stub := newStubLLM(t, http.StatusOK, "Короткое содержание статьи.", openai.FinishReasonStop)
+	usage := &fakeUsage{}
+
+	// Self-hosted серверы часто работают без ключа, достаточно адреса
+	s := newTestSummarizer("", stub.server.URL+"/v1", usage)
+	if !s.Enabled() {
+		t.Fatal("summarizer with base url is disabled")
+	}
+
+	summary, err := s.Summarize(context.Background(), model.SummaryRequest{ArticleID: 7, SourceID: 3, Text: "Текст статьи"})
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+
+	if summary.Text != "Короткое содержание статьи." {
+		t.Errorf("summary = %q", summary.Text)
+	}
+	if summary.Model != "llama-3-8b-instruct" {
+		t.Errorf("summary model = %q, want llama-3-8b-instruct", summary.Model)
+	}
+	if summary.PromptVersion != PromptVersion("Сделай краткое содержание") {
+		t.Errorf("summary prompt version = %q", summary.PromptVersion)
+	}
+
+	calls := stub.calls()
+	if len(calls) != 1 {
+		t.Fatalf("server received %d requests, want 1", len(calls))
+	}
+
+	req := calls[0]
+	if req.Model != "llama-3-8b-instruct" || req.Temperature != 0.2 || req.MaxTokens != 128 {
+		t.Errorf("request model = %q, temperature = %v, max tokens = %d", req.Model, req.Temperature, req.MaxTokens)
+	}
+	if len(req.Messages) != 2 ||
+		req.Messages[0].Role != openai.ChatMessageRoleSystem || req.Messages[0].Content != "Сделай краткое содержание" ||
+		req.Messages[1].Role != openai.ChatMessageRoleUser || req.Messages[1].Content != "Текст статьи" {
+		t.Errorf("request messages = %+v", req.Messages)
+	}
+
+	if len(usage.usage) != 1 {
+		t.Fatalf("recorded %d usages, want 1", len(usage.usage))
+	}
+	if got := usage.usage[0]; got.ArticleID != 7 || got.SourceID != 3 || got.Model != "llama-3-8b-instruct" ||
+		got.PromptTokens != 120 || got.CompletionTokens != 30 {
+		t.Errorf("recorded usage = %+v", got)
+	}
+}
+
+func TestOpenAISummarizerAPIKey(t *testing.T) {
+	stub := newStubLLM(t, http.StatusOK, "Summary.", openai.FinishReasonStop)
+
+	s := newTestSummarizer("sk-test", stub.server.URL+"/v1", &fakeUsage{})
+	if _, err := s.Summarize(context.Background(), model.SummaryRequest{Text: "Текст"}); err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+
+	if got := stub.headers[0].Get("Authorization"); got != "Bearer sk-test" {
+		t.Errorf("Authorization = %q, want Bearer sk-test", got)
+	}
+}
+
+func TestOpenAISummarizerDisabled(t *testing.T) {
+	s := newTestSummarizer("", "", &fakeUsage{})
+	if s.Enabled() {
+		t.Fatal("summarizer without key and base url is enabled")
+	}
+
+	summary, err := s.Summarize(context.Background(), model.SummaryRequest{Text: "Текст"})
+	if err != nil || summary.Text != "" {
+		t.Errorf("Summarize() = %q, %v, want empty summary", summary.Text, err)
+	}
+}
+
+func TestOpenAISummarizerCutOff(t *testing.T) {
+	// Модель уперлась в max tokens: незаконченное предложение отбрасывается
+	stub := newStubLLM(t, http.StatusOK, "Первое предложение. Второе предложение. Третье обор", openai.FinishReasonLength)
+
+	summary, err := newTestSummarizer("", stub.server.URL+"/v1", &fakeUsage{}).
+		Summarize(context.Background(), model.SummaryRequest{Text: "Текст"})
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+
+	if summary.Text != "Первое предложение. Второе предложение." {
+		t.Errorf("summary = %q, want only finished sentences", summary.Text)
+	}
+}
+
+func TestOpenAISummarizerServerError(t *testing.T) {
+	stub := newStubLLM(t, http.StatusServiceUnavailable, "", "")
+	usage := &fakeUsage{}
+
+	_, err := newTestSummarizer("", stub.server.URL+"/v1", usage).
+		Summarize(context.Background(), model.SummaryRequest{Text: "Текст"})
+	if err == nil || !strings.Contains(err.Error(), "model is overloaded") {
+		t.Fatalf("Summarize() error = %v, want server error", err)
+	}
+
+	if len(usage.usage) != 0 {
+		t.Errorf("usage of failed request was recorded")
+	}
+}
@@ -0,0 +1,90 @@
+package summary
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// Переменные, доступные в шаблоне промпта источника.
+// Language - язык ответа из настроек источника, InputLanguage - язык самой статьи, пустой если не определен
+type PromptData struct {
+	Title         string
+	Source        string
+	Language      string
+	InputLanguage string
+}
+
+// Рендерит шаблон промпта text/template
+func RenderPrompt(tmpl string, data PromptData) (string, error) {
+	t, err := template.New("prompt").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Проверяет шаблон промпта на тестовых данных, чтобы ошибка всплыла при настройке, а не при отправке статьи
+func ValidatePrompt(tmpl string) error {
+	_, err := RenderPrompt(tmpl, PromptData{
+		Title:         "Sample title",
+		Source:        "Sample source",
+		Language:      "ru",
+		InputLanguage: "en",
+	})
+	return err
+}
+
+// Дополнения к системному промпту про язык статьи, язык и длину ответа
+func promptConstraints(inputLanguage string, language string, maxLength int) string {
+	var constraints []string
+
+	if inputLanguage != "" {
+		constraints = append(constraints, fmt.Sprintf("Текст статьи написан на языке: %s.", inputLanguage))
+	}
+
+	if language != "" {
+		constraints = append(constraints, fmt.Sprintf("Пиши ответ на языке: %s.", language))
+	}
+
+	if maxLength > 0 {
+		constraints = append(constraints, fmt.Sprintf("Ответ должен быть не длиннее %d символов.", maxLength))
+	}
+
+	return strings.Join(constraints, " ")
+}
+
+// Обрезает summary до maxLength символов по границе предложения.
+// Если уже первое предложение длиннее, режет его по символам
+func limitLength(text string, maxLength int) string {
+	if maxLength <= 0 || utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+
+	var b strings.Builder
+	for _, sentence := range splitSentences(text) {
+		next := sentence
+		if b.Len() > 0 {
+			next = " " + sentence
+		}
+
+		if utf8.RuneCountInString(b.String()+next) > maxLength {
+			break
+		}
+		b.WriteString(next)
+	}
+
+	if b.Len() > 0 {
+		return b.String()
+	}
+
+	return strings.TrimSpace(string([]rune(text)[:maxLength-1])) + "…"
+}
@@ -0,0 +1,68 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"math"
+	"strings"
+)
+
+const relevancePromt = `Ты редактор. Оцени от 0 до 100, насколько статья подходит изданию с такой редакционной политикой:
+%s
+
+Ответь только JSON объектом вида {"score": 75, "reason": "..."} без пояснений.
+В reason одним коротким предложением объясни оценку.`
+
+// Для оценки хватает начала статьи
+const relevanceInputTokens = 1500
+
+// Оценка релевантности статей редакционному брифу через тот же LLM бэкенд, что генерирует summary
+type OpenAIScorer struct {
+	llm *OpenAISummarizer
+}
+
+func NewOpenAIScorer(llm *OpenAISummarizer) *OpenAIScorer {
+	return &OpenAIScorer{llm: llm}
+}
+
+// Возвращает оценку от 0 до 100 и ее объяснение. Поля статьи и места назначения заполняет вызывающий
+func (s *OpenAIScorer) Score(ctx context.Context, req model.RelevanceRequest) (model.RelevanceScore, error) {
+	release, err := s.llm.acquire(ctx)
+	if err != nil {
+		return model.RelevanceScore{}, err
+	}
+	defer release()
+
+	system := fmt.Sprintf(relevancePromt, strings.TrimSpace(req.Brief))
+	user := req.Title + "\n\n" + truncateTokens(req.Text, relevanceInputTokens)
+
+	choice, err := s.llm.chat(ctx, req.ArticleID, req.SourceID, system, user, 128)
+	if err != nil {
+		return model.RelevanceScore{}, err
+	}
+
+	var answer struct {
+		// Модель иногда отвечает дробной оценкой, например 75.5
+		Score  *float64 `json:"score"`
+		Reason string   `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(trimCodeFence(choice.Message.Content)), &answer); err != nil {
+		return model.RelevanceScore{}, fmt.Errorf("parse relevance score: %w", err)
+	}
+
+	if answer.Score == nil {
+		return model.RelevanceScore{}, fmt.Errorf("no score in answer: %q", choice.Message.Content)
+	}
+
+	score := int(math.Round(*answer.Score))
+	if score < 0 {
+		score = 0
+	}
+	if score > 100 {
+		score = 100
+	}
+
+	return model.RelevanceScore{Score: score, Reason: strings.TrimSpace(answer.Reason)}, nil
+}
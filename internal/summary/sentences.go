@@ -0,0 +1,125 @@
+package summary
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Сокращения, после точки в которых предложение не заканчивается
+var abbreviations = map[string]struct{}{
+	// Русские
+	"др": {}, "пр": {}, "гг": {}, "вв": {},
+	"см": {}, "стр": {}, "рис": {}, "тыс": {}, "млн": {}, "млрд": {}, "руб": {}, "коп": {},
+	"им": {}, "ул": {}, "д-р": {}, "проф": {}, "акад": {}, "напр": {}, "т.е": {}, "т.д": {}, "т.п": {},
+	// Английские
+	"mr": {}, "mrs": {}, "ms": {}, "dr": {}, "prof": {}, "sr": {}, "jr": {}, "st": {}, "vs": {},
+	"etc": {}, "inc": {}, "ltd": {}, "co": {}, "corp": {}, "e.g": {}, "i.e": {}, "u.s": {},
+	"jan": {}, "feb": {}, "mar": {}, "apr": {}, "jun": {}, "jul": {}, "aug": {}, "sep": {}, "sept": {},
+	"oct": {}, "nov": {}, "dec": {}, "fig": {}, "approx": {},
+}
+
+// Делит текст на предложения. Абзацы всегда разделяются,
+// а внутри абзаца предложение заканчивается на . ! ? или …, за которыми идет пробел и заглавная буква, цифра или кавычка
+func splitSentences(text string) []string {
+	var sentences []string
+
+	for _, paragraph := range strings.Split(text, "\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+
+		sentences = append(sentences, splitParagraph(paragraph)...)
+	}
+
+	return sentences
+}
+
+func splitParagraph(paragraph string) []string {
+	runes := []rune(paragraph)
+
+	var (
+		sentences []string
+		start     int
+	)
+
+	for i := 0; i < len(runes); i++ {
+		if !isSentenceEnd(runes[i]) {
+			continue
+		}
+
+		// Многоточие и ?! считаем одним концом предложения
+		end := i
+		for end+1 < len(runes) && (isSentenceEnd(runes[end+1]) || isClosingQuote(runes[end+1])) {
+			end++
+		}
+
+		if end+1 < len(runes) && !unicode.IsSpace(runes[end+1]) {
+			i = end
+			continue
+		}
+
+		next := end + 1
+		for next < len(runes) && unicode.IsSpace(runes[next]) {
+			next++
+		}
+
+		if next < len(runes) && !startsSentence(runes[next]) {
+			i = end
+			continue
+		}
+
+		if runes[i] == '.' && isAbbreviation(runes[start:i]) {
+			i = end
+			continue
+		}
+
+		sentences = appendSentence(sentences, string(runes[start:end+1]))
+		start = next
+		i = end
+	}
+
+	return appendSentence(sentences, string(runes[start:]))
+}
+
+func appendSentence(sentences []string, sentence string) []string {
+	sentence = strings.TrimSpace(sentence)
+	if sentence == "" {
+		return sentences
+	}
+
+	return append(sentences, sentence)
+}
+
+func isSentenceEnd(r rune) bool {
+	return r == '.' || r == '!' || r == '?' || r == '…'
+}
+
+func isClosingQuote(r rune) bool {
+	return r == '"' || r == '»' || r == '”' || r == '\'' || r == ')'
+}
+
+func startsSentence(r rune) bool {
+	return unicode.IsUpper(r) || unicode.IsDigit(r) || r == '"' || r == '«' || r == '“' || r == '—' || r == '-'
+}
+
+// Проверяет, что перед точкой стоит сокращение или инициал
+func isAbbreviation(before []rune) bool {
+	i := len(before)
+	for i > 0 && !unicode.IsSpace(before[i-1]) && before[i-1] != '(' {
+		i--
+	}
+
+	word := strings.ToLower(string(before[i:]))
+	if word == "" {
+		return false
+	}
+
+	// Инициалы: «А. С. Пушкин», «J. R. R. Tolkien»
+	if len([]rune(word)) == 1 && unicode.IsLetter([]rune(word)[0]) {
+		return true
+	}
+
+	_, ok := abbreviations[word]
+	return ok
+}
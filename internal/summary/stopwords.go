@@ -0,0 +1,43 @@
+package summary
+
+// Стоп-слова русского и английского языков. Они встречаются почти в каждом предложении и не говорят о его смысле,
+// поэтому не учитываются при сравнении предложений
+var stopwords = map[string]struct{}{
+	// Русские
+	"а": {}, "без": {}, "более": {}, "будет": {}, "будут": {}, "бы": {}, "был": {}, "была": {}, "были": {},
+	"было": {}, "быть": {}, "в": {}, "вам": {}, "вас": {}, "весь": {}, "во": {}, "вот": {}, "все": {},
+	"всего": {}, "всех": {}, "вы": {}, "где": {}, "да": {}, "даже": {}, "для": {}, "до": {}, "его": {},
+	"ее": {}, "ей": {}, "ему": {}, "если": {}, "есть": {}, "еще": {}, "же": {}, "за": {}, "здесь": {}, "и": {},
+	"из": {}, "или": {}, "им": {}, "их": {}, "к": {}, "как": {}, "какой": {}, "когда": {}, "которая": {},
+	"которое": {}, "которые": {}, "который": {}, "которых": {}, "кто": {}, "ли": {}, "либо": {}, "между": {},
+	"менее": {}, "меня": {}, "мне": {}, "много": {}, "могут": {}, "может": {}, "можно": {}, "мы": {}, "на": {},
+	"над": {}, "надо": {}, "наш": {}, "не": {}, "него": {}, "нее": {}, "нет": {}, "ни": {}, "них": {}, "но": {},
+	"ну": {}, "о": {}, "об": {}, "однако": {}, "он": {}, "она": {}, "они": {}, "оно": {}, "от": {}, "очень": {},
+	"по": {}, "под": {}, "после": {}, "потом": {}, "потому": {}, "при": {}, "про": {}, "с": {}, "сам": {},
+	"своей": {}, "своих": {}, "свой": {}, "свою": {}, "себе": {}, "себя": {}, "со": {}, "так": {}, "также": {},
+	"такой": {}, "там": {}, "те": {}, "тем": {}, "то": {}, "того": {}, "тоже": {}, "той": {}, "только": {},
+	"том": {}, "тот": {}, "ту": {}, "ты": {}, "у": {}, "уже": {}, "хотя": {}, "чего": {}, "чей": {}, "чем": {},
+	"что": {}, "чтобы": {}, "чье": {}, "эта": {}, "эти": {}, "этим": {}, "этих": {}, "это": {}, "этого": {},
+	"этой": {}, "этом": {}, "этот": {}, "я": {},
+	// Английские
+	"a": {}, "about": {}, "above": {}, "after": {}, "again": {}, "against": {}, "all": {}, "also": {}, "am": {},
+	"an": {}, "and": {}, "any": {}, "are": {}, "as": {}, "at": {}, "be": {}, "because": {}, "been": {},
+	"before": {}, "being": {}, "below": {}, "between": {}, "both": {}, "but": {}, "by": {}, "can": {},
+	"could": {}, "did": {}, "do": {}, "does": {}, "doing": {}, "down": {}, "during": {}, "each": {}, "few": {},
+	"for": {}, "from": {}, "further": {}, "had": {}, "has": {}, "have": {}, "having": {}, "he": {}, "her": {},
+	"here": {}, "hers": {}, "herself": {}, "him": {}, "himself": {}, "his": {}, "how": {}, "i": {}, "if": {},
+	"in": {}, "into": {}, "is": {}, "it": {}, "its": {}, "itself": {}, "just": {}, "me": {}, "more": {},
+	"most": {}, "my": {}, "myself": {}, "no": {}, "nor": {}, "not": {}, "now": {}, "of": {}, "off": {},
+	"on": {}, "once": {}, "only": {}, "or": {}, "other": {}, "our": {}, "ours": {}, "ourselves": {}, "out": {},
+	"over": {}, "own": {}, "same": {}, "she": {}, "should": {}, "so": {}, "some": {}, "such": {}, "than": {},
+	"that": {}, "the": {}, "their": {}, "theirs": {}, "them": {}, "themselves": {}, "then": {}, "there": {},
+	"these": {}, "they": {}, "this": {}, "those": {}, "through": {}, "to": {}, "too": {}, "under": {},
+	"until": {}, "up": {}, "very": {}, "was": {}, "we": {}, "were": {}, "what": {}, "when": {}, "where": {},
+	"which": {}, "while": {}, "who": {}, "whom": {}, "why": {}, "will": {}, "with": {}, "would": {}, "you": {},
+	"your": {}, "yours": {}, "yourself": {}, "yourselves": {},
+}
+
+func isStopword(word string) bool {
+	_, ok := stopwords[word]
+	return ok
+}
@@ -0,0 +1,198 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+// Дополнение к системному промпту в структурированном режиме
+const structuredPromt = `Ответь только JSON объектом без пояснений и markdown вида
+{"tldr": "...", "bullets": ["...", "..."], "entities": ["...", "..."]}
+tldr - главная мысль статьи одним предложением, bullets - от 3 до 5 ключевых фактов,
+entities - упомянутые компании, люди, продукты и технологии.`
+
+const (
+	// Сколько раз просим модель ответить, если она вернула некорректный JSON
+	maxStructuredAttempts = 2
+	// Ограничения на размер структурированного summary
+	minBullets  = 3
+	maxBullets  = 5
+	maxEntities = 10
+	// Короче этого пункты и TL;DR не сокращаются, чтобы в них оставался смысл
+	minItemLength = 20
+	// JSON занимает больше токенов, чем текст, поэтому на ответ даем не меньше этого
+	minStructuredTokens = 512
+)
+
+// Ответ модели в структурированном режиме
+type structuredSummary struct {
+	TLDR     string   `json:"tldr"`
+	Bullets  []string `json:"bullets"`
+	Entities []string `json:"entities"`
+}
+
+// Генерирует структурированное summary. Если модель вернула некорректный JSON, просит еще раз, указав на ошибку
+func (s *OpenAISummarizer) completeStructured(ctx context.Context, req model.SummaryRequest, promt string, text string) (model.Summary, error) {
+	maxTokens := s.maxTokens
+	if maxTokens < minStructuredTokens {
+		maxTokens = minStructuredTokens
+	}
+
+	system := promt + "\n" + structuredPromt
+
+	var lastErr error
+	for attempt := 0; attempt < maxStructuredAttempts; attempt++ {
+		instruction := system
+		if lastErr != nil {
+			instruction += fmt.Sprintf("\nПредыдущий ответ был некорректным (%v). Верни только валидный JSON указанного вида.", lastErr)
+		}
+
+		choice, err := s.chat(ctx, req.ArticleID, req.SourceID, instruction, text, maxTokens)
+		if err != nil {
+			return model.Summary{}, err
+		}
+
+		structured, err := parseStructured(choice.Message.Content)
+		if err != nil {
+			log.Printf("[WARN] malformed structured summary of article %d (attempt %d): %v", req.ArticleID, attempt+1, err)
+			lastErr = err
+			continue
+		}
+
+		structured.fit(req.MaxLength)
+
+		return model.Summary{
+			// После fit обрезка срабатывает только при лимите, в который не влезают даже сокращенные пункты
+			Text:          limitLength(structured.text(), req.MaxLength),
+			TLDR:          structured.TLDR,
+			Bullets:       structured.Bullets,
+			Entities:      structured.Entities,
+			Model:         s.model,
+			PromptVersion: PromptVersion(system),
+		}, nil
+	}
+
+	return model.Summary{}, fmt.Errorf("structured summary: %w", lastErr)
+}
+
+// Разбирает и проверяет ответ модели. Лишние пункты отбрасываются, пустые строки убираются
+func parseStructured(content string) (structuredSummary, error) {
+	var result structuredSummary
+	if err := json.Unmarshal([]byte(trimCodeFence(content)), &result); err != nil {
+		return structuredSummary{}, fmt.Errorf("invalid json: %w", err)
+	}
+
+	result.TLDR = oneLine(result.TLDR)
+	result.Bullets = cleanItems(result.Bullets, maxBullets)
+	result.Entities = cleanItems(result.Entities, maxEntities)
+
+	if result.TLDR == "" {
+		return structuredSummary{}, errors.New("empty tldr")
+	}
+
+	if len(result.Bullets) < minBullets {
+		return structuredSummary{}, fmt.Errorf("%d bullets, want from %d to %d", len(result.Bullets), minBullets, maxBullets)
+	}
+
+	return result, nil
+}
+
+// Вписывает текстовую версию в maxLength символов: сначала убирает последние пункты, оставляя не меньше minBullets,
+// затем сокращает самый длинный из TL;DR и пунктов, пока текст не поместится
+func (s *structuredSummary) fit(maxLength int) {
+	if maxLength <= 0 {
+		return
+	}
+
+	for len(s.Bullets) > minBullets && utf8.RuneCountInString(s.text()) > maxLength {
+		s.Bullets = s.Bullets[:len(s.Bullets)-1]
+	}
+
+	for {
+		excess := utf8.RuneCountInString(s.text()) - maxLength
+		if excess <= 0 {
+			return
+		}
+
+		item := s.longest()
+		length := utf8.RuneCountInString(*item)
+		if length <= minItemLength {
+			return
+		}
+
+		target := length - excess
+		if target < minItemLength {
+			target = minItemLength
+		}
+		*item = shorten(*item, target)
+	}
+}
+
+// Самый длинный из TL;DR и пунктов
+func (s *structuredSummary) longest() *string {
+	longest := &s.TLDR
+	for i := range s.Bullets {
+		if utf8.RuneCountInString(s.Bullets[i]) > utf8.RuneCountInString(*longest) {
+			longest = &s.Bullets[i]
+		}
+	}
+
+	return longest
+}
+
+// Текстовая версия для мест назначения и шаблонов, которые не используют структуру
+func (s structuredSummary) text() string {
+	var b strings.Builder
+	b.WriteString(s.TLDR)
+
+	if len(s.Bullets) > 0 {
+		b.WriteString("\n")
+	}
+
+	for _, bullet := range s.Bullets {
+		b.WriteString("\n• " + bullet)
+	}
+
+	return b.String()
+}
+
+func cleanItems(items []string, limit int) []string {
+	result := make([]string, 0, len(items))
+
+	for _, item := range items {
+		if item = oneLine(item); item != "" {
+			result = append(result, item)
+		}
+
+		if len(result) == limit {
+			break
+		}
+	}
+
+	return result
+}
+
+// Сокращает строку до n символов по границе слова, добавляя многоточие
+func shorten(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+
+	cut := strings.TrimSpace(string(runes[:n-1]))
+	if i := strings.LastIndex(cut, " "); i > len(cut)/2 {
+		cut = strings.TrimRight(cut[:i], " ,;:—-")
+	}
+
+	return cut + "…"
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
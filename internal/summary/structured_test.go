@@ -0,0 +1,83 @@
+package summary
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseStructuredBullets(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "too few bullets",
+			content: `{"tldr": "Главное", "bullets": ["Первый", "  ", "Второй"]}`,
+			wantErr: true,
+		},
+		{
+			name:    "minimum bullets",
+			content: `{"tldr": "Главное", "bullets": ["Первый", "Второй", "Третий"]}`,
+			want:    3,
+		},
+		{
+			name:    "extra bullets are dropped",
+			content: "```json\n" + `{"tldr": "Главное", "bullets": ["1", "2", "3", "4", "5", "6", "7"]}` + "\n```",
+			want:    maxBullets,
+		},
+		{
+			name:    "empty tldr",
+			content: `{"tldr": " ", "bullets": ["Первый", "Второй", "Третий"]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStructured(tt.content)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseStructured() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseStructured() error = %v", err)
+			}
+			if len(got.Bullets) != tt.want {
+				t.Errorf("parseStructured() has %d bullets, want %d", len(got.Bullets), tt.want)
+			}
+		})
+	}
+}
+
+func TestStructuredFit(t *testing.T) {
+	sentence := strings.Repeat("слово ", 20)
+	long := structuredSummary{
+		TLDR:    "Главная мысль: " + sentence,
+		Bullets: []string{"Первый " + sentence, "Второй " + sentence, "Третий " + sentence, "Четвертый " + sentence, "Пятый " + sentence},
+	}
+
+	for _, maxLength := range []int{600, 400, 200, 100} {
+		s := structuredSummary{TLDR: long.TLDR, Bullets: append([]string(nil), long.Bullets...)}
+		s.fit(maxLength)
+
+		text := s.text()
+		if n := utf8.RuneCountInString(text); n > maxLength {
+			t.Errorf("fit(%d) text is %d characters", maxLength, n)
+		}
+		if len(s.Bullets) < minBullets {
+			t.Errorf("fit(%d) left %d bullets, want at least %d", maxLength, len(s.Bullets), minBullets)
+		}
+		// Пункты сокращаются целиком, а не обрезаются посреди списка
+		if strings.Count(text, "\n• ") != len(s.Bullets) {
+			t.Errorf("fit(%d) text %q does not contain all %d bullets", maxLength, text, len(s.Bullets))
+		}
+		if limitLength(text, maxLength) != text {
+			t.Errorf("fit(%d) text would be cut by limitLength", maxLength)
+		}
+	}
+}
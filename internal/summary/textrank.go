@@ -0,0 +1,205 @@
+package summary
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+)
+
+// Имя, под которым экстрактивные summary сохраняются в БД вместо модели
+const TextRankModel = "textrank"
+
+const (
+	// Коэффициент затухания и число итераций PageRank, как в оригинальной статье про TextRank
+	textRankDamping    = 0.85
+	textRankIterations = 50
+	textRankEpsilon    = 1e-4
+	// Предложения короче этого числа значимых слов в summary не берем: обычно это подписи и обрывки
+	minSentenceWords = 3
+)
+
+// Экстрактивный summarizer без обращения к сети.
+// Выбирает из текста самые важные предложения по алгоритму TextRank: предложения связываются по общим словам,
+// а вес предложения считается как PageRank на этом графе. Поддерживает русский и английский текст
+type TextRankSummarizer struct {
+	// Сколько предложений оставлять в summary
+	maxSentences int
+}
+
+func NewTextRankSummarizer(maxSentences int) *TextRankSummarizer {
+	return &TextRankSummarizer{maxSentences: maxSentences}
+}
+
+// Промпт и язык экстрактивному summarizer не нужны и игнорируются, учитывается только максимальная длина
+func (s *TextRankSummarizer) Summarize(_ context.Context, req model.SummaryRequest) (model.Summary, error) {
+	return model.Summary{Text: limitLength(s.extract(req.Text), req.MaxLength), Model: TextRankModel}, nil
+}
+
+func (s *TextRankSummarizer) extract(text string) string {
+	sentences := splitSentences(text)
+
+	// Слова каждого предложения без стоп-слов
+	words := make([][]string, len(sentences))
+	// Индексы предложений, которые участвуют в ранжировании
+	candidates := make([]int, 0, len(sentences))
+
+	for i, sentence := range sentences {
+		words[i] = significantWords(sentence)
+		if len(words[i]) >= minSentenceWords {
+			candidates = append(candidates, i)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	if len(candidates) <= s.maxSentences {
+		return joinSentences(sentences, candidates)
+	}
+
+	scores := textRank(words, candidates)
+
+	// Берем лучшие предложения, но выводим их в исходном порядке, чтобы текст читался связно
+	ranked := append([]int(nil), candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i]] > scores[ranked[j]]
+	})
+
+	selected := ranked[:s.maxSentences]
+	sort.Ints(selected)
+
+	return joinSentences(sentences, selected)
+}
+
+// Считает вес каждого предложения из candidates
+func textRank(words [][]string, candidates []int) map[int]float64 {
+	n := len(candidates)
+
+	// Матрица сходства предложений и сумма весов исходящих ребер каждого предложения
+	similarity := make([][]float64, n)
+	outWeight := make([]float64, n)
+
+	for i := range candidates {
+		similarity[i] = make([]float64, n)
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			sim := sentenceSimilarity(words[candidates[i]], words[candidates[j]])
+			similarity[i][j] = sim
+			similarity[j][i] = sim
+			outWeight[i] += sim
+			outWeight[j] += sim
+		}
+	}
+
+	scores := make([]float64, n)
+	for i := range scores {
+		scores[i] = 1
+	}
+
+	for iteration := 0; iteration < textRankIterations; iteration++ {
+		next := make([]float64, n)
+		delta := 0.0
+
+		for i := 0; i < n; i++ {
+			rank := 0.0
+			for j := 0; j < n; j++ {
+				if similarity[j][i] == 0 || outWeight[j] == 0 {
+					continue
+				}
+				rank += similarity[j][i] / outWeight[j] * scores[j]
+			}
+
+			next[i] = 1 - textRankDamping + textRankDamping*rank
+			delta += math.Abs(next[i] - scores[i])
+		}
+
+		scores = next
+		if delta < textRankEpsilon {
+			break
+		}
+	}
+
+	result := make(map[int]float64, n)
+	for i, index := range candidates {
+		result[index] = scores[i]
+	}
+
+	return result
+}
+
+// Сходство предложений из TextRank: число общих слов, нормированное на длины предложений
+func sentenceSimilarity(a []string, b []string) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return 0
+	}
+
+	set := make(map[string]struct{}, len(a))
+	for _, word := range a {
+		set[word] = struct{}{}
+	}
+
+	common := 0
+	seen := make(map[string]struct{}, len(b))
+	for _, word := range b {
+		if _, ok := seen[word]; ok {
+			continue
+		}
+		seen[word] = struct{}{}
+
+		if _, ok := set[word]; ok {
+			common++
+		}
+	}
+
+	if common == 0 {
+		return 0
+	}
+
+	return float64(common) / (math.Log(float64(len(a))) + math.Log(float64(len(b))))
+}
+
+// Слова предложения в нижнем регистре без стоп-слов и коротких служебных слов.
+// Окончания грубо отрезаются, чтобы разные формы одного слова считались общими
+func significantWords(sentence string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(sentence), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	words := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if len([]rune(field)) < 2 || isStopword(field) {
+			continue
+		}
+
+		words = append(words, stem(field))
+	}
+
+	return words
+}
+
+// Очень простой стемминг: оставляем первые 6 букв длинных слов.
+// Для русского этого хватает, чтобы «сервер», «сервера» и «серверами» совпали
+func stem(word string) string {
+	runes := []rune(word)
+	if len(runes) > 6 {
+		return string(runes[:6])
+	}
+
+	return word
+}
+
+func joinSentences(sentences []string, indexes []int) string {
+	parts := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		parts = append(parts, sentences[index])
+	}
+
+	return strings.Join(parts, " ")
+}
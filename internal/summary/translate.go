@@ -0,0 +1,124 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kovalyov-valentin/news-feed-bot/internal/model"
+	"strings"
+)
+
+const translatePromt = `Переведи заголовок и краткое содержание новости с языка %s на язык %s.
+Новость передана JSON объектом. Ответь только JSON объектом с теми же полями и тем же числом элементов в списках без пояснений.
+В поле entities имена и названия: переводи их, только если у них есть общепринятое написание на языке перевода.%s`
+
+// Перевод заголовков и summary через тот же LLM бэкенд, что генерирует summary.
+// Термины из глоссария модель просят оставить без перевода
+type OpenAITranslator struct {
+	llm      *OpenAISummarizer
+	glossary []string
+}
+
+func NewOpenAITranslator(llm *OpenAISummarizer, glossary []string) *OpenAITranslator {
+	return &OpenAITranslator{llm: llm, glossary: glossary}
+}
+
+func (t *OpenAITranslator) Translate(ctx context.Context, req model.TranslationRequest) (model.Translation, error) {
+	release, err := t.llm.acquire(ctx)
+	if err != nil {
+		return model.Translation{}, err
+	}
+	defer release()
+
+	from := req.From
+	if from == "" {
+		from = "оригинала"
+	}
+
+	var glossary string
+	if terms := t.terms(req.Title + "\n" + req.Summary + "\n" + strings.Join(req.Entities, "\n")); len(terms) > 0 {
+		glossary = "\nНе переводи и оставь как есть эти термины: " + strings.Join(terms, ", ") + "."
+	}
+
+	input, err := json.Marshal(translation{
+		Title:    req.Title,
+		Summary:  req.Summary,
+		TLDR:     req.TLDR,
+		Bullets:  req.Bullets,
+		Entities: req.Entities,
+	})
+	if err != nil {
+		return model.Translation{}, err
+	}
+
+	// Перевод примерно той же длины, что и оригинал, плюс запас на разметку JSON
+	maxTokens := estimateTokens(string(input))*2 + 64
+
+	choice, err := t.llm.chat(ctx, req.ArticleID, req.SourceID, fmt.Sprintf(translatePromt, from, req.To, glossary), string(input), maxTokens)
+	if err != nil {
+		return model.Translation{}, err
+	}
+
+	var result translation
+	if err := json.Unmarshal([]byte(trimCodeFence(choice.Message.Content)), &result); err != nil {
+		return model.Translation{}, fmt.Errorf("parse translation: %w", err)
+	}
+
+	if result.Title == "" ||
+		(req.Summary != "" && result.Summary == "") ||
+		(req.TLDR != "" && result.TLDR == "") ||
+		len(result.Bullets) != len(req.Bullets) {
+		return model.Translation{}, fmt.Errorf("incomplete translation: %q", choice.Message.Content)
+	}
+
+	// Имена без перевода лучше, чем перепутанные: если модель потеряла или добавила сущность, оставляем оригинал
+	if len(result.Entities) != len(req.Entities) {
+		result.Entities = req.Entities
+	}
+
+	return model.Translation{
+		ArticleID: req.ArticleID,
+		Language:  req.To,
+		Title:     result.Title,
+		Summary:   result.Summary,
+		TLDR:      result.TLDR,
+		Bullets:   result.Bullets,
+		Entities:  result.Entities,
+	}, nil
+}
+
+// Термины глоссария, которые встречаются в тексте. Остальные в промпт не передаем, чтобы не тратить токены
+func (t *OpenAITranslator) terms(text string) []string {
+	lower := strings.ToLower(text)
+
+	var terms []string
+	for _, term := range t.glossary {
+		if term != "" && strings.Contains(lower, strings.ToLower(term)) {
+			terms = append(terms, term)
+		}
+	}
+
+	return terms
+}
+
+type translation struct {
+	Title    string   `json:"title"`
+	Summary  string   `json:"summary"`
+	TLDR     string   `json:"tldr,omitempty"`
+	Bullets  []string `json:"bullets,omitempty"`
+	Entities []string `json:"entities,omitempty"`
+}
+
+// Модели любят оборачивать JSON в блок кода markdown, убираем его
+func trimCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimPrefix(s, "json")
+	s = strings.TrimSuffix(s, "```")
+
+	return strings.TrimSpace(s)
+}